@@ -1,11 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/atotto/clipboard"
 	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/git"
+	"github.com/donbowman/github-multi-account-manager/internal/secrets"
 	"github.com/donbowman/github-multi-account-manager/internal/ssh"
 )
 
@@ -17,12 +23,24 @@ func main() {
 
 	command := os.Args[1]
 
+	// Handled before loading the config, which would migrate it
+	if command == "config" {
+		configCommand(os.Args[2:])
+		return
+	}
+
 	cfg, err := config.New("")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Warn rather than fail, so list, edit and remove can still fix the file.
+	// The credential helper stays quiet because git shows its stderr.
+	if err := cfg.Problems(); err != nil && command != "credential" {
+		printProblems(cfg.ConfigFile(), err)
+	}
+
 	sshMgr, err := ssh.New()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing SSH manager: %v\n", err)
@@ -32,10 +50,10 @@ func main() {
 	switch command {
 	case "add":
 		if len(os.Args) < 6 {
-			fmt.Println("Usage: ghmm-cli add <name> <username> <email> <directory>")
+			fmt.Println("Usage: ghmm-cli add <name> <username> <email> <directory> [directory...]")
 			os.Exit(1)
 		}
-		addAccount(cfg, os.Args[2], os.Args[3], os.Args[4], os.Args[5])
+		addAccount(cfg, os.Args[2], os.Args[3], os.Args[4], os.Args[5:]...)
 
 	case "list":
 		listAccounts(cfg)
@@ -49,10 +67,19 @@ func main() {
 
 	case "generate-key":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: ghmm-cli generate-key <account-name>")
+			fmt.Println("Usage: ghmm-cli generate-key <account-name> [--type ed25519|ed25519-sk|ecdsa-sk] [--resident]")
+			os.Exit(1)
+		}
+		opts, _ := parseKeyFlags(os.Args[3:])
+		generateKey(cfg, sshMgr, os.Args[2], opts)
+
+	case "generate-signing-key":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: ghmm-cli generate-signing-key <account-name> [path] [--type ed25519|ed25519-sk|ecdsa-sk] [--resident]")
 			os.Exit(1)
 		}
-		generateKey(cfg, sshMgr, os.Args[2])
+		opts, args := parseKeyFlags(os.Args[3:])
+		generateSigningKey(cfg, sshMgr, os.Args[2], args, opts)
 
 	case "test":
 		if len(os.Args) < 3 {
@@ -61,6 +88,62 @@ func main() {
 		}
 		testConnection(cfg, sshMgr, os.Args[2])
 
+	case "remote-patterns":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: ghmm-cli remote-patterns <account-name> [pattern...]")
+			os.Exit(1)
+		}
+		remotePatterns(cfg, os.Args[2], os.Args[3:])
+
+	case "orgs":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: ghmm-cli orgs <account-name> [org...]")
+			os.Exit(1)
+		}
+		accountOrgs(cfg, os.Args[2], os.Args[3:])
+
+	case "rewrite-urls":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: ghmm-cli rewrite-urls <account-name> [on|off]")
+			os.Exit(1)
+		}
+		rewriteURLs(cfg, os.Args[2], os.Args[3:])
+
+	case "ssh-mode":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: ghmm-cli ssh-mode <account-name> [alias|command]")
+			os.Exit(1)
+		}
+		sshMode(cfg, os.Args[2], os.Args[3:])
+
+	case "signing":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: ghmm-cli signing <account-name> [none|ssh|gpg|x509] [--key <key>] [--commits] [--tags]")
+			os.Exit(1)
+		}
+		signing(cfg, os.Args[2], os.Args[3:])
+
+	case "signers":
+		signers(cfg, os.Args[2:])
+
+	case "verify":
+		verify(cfg, os.Args[2:])
+
+	case "credential":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: ghmm-cli credential <get|store|erase>")
+			os.Exit(1)
+		}
+		credential(cfg, os.Args[2])
+
+	case "token":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: ghmm-cli token <set|get|rm> <account-name>")
+			fmt.Printf("The vault passphrase is read from $%s, or asked for on the terminal\n", secrets.PassphraseEnv)
+			os.Exit(1)
+		}
+		token(cfg, os.Args[2], os.Args[3])
+
 	case "set-default":
 		if len(os.Args) < 3 {
 			fmt.Println("Usage: ghmm-cli set-default <account-name>")
@@ -71,6 +154,20 @@ func main() {
 	case "setup":
 		setupWizard(cfg, sshMgr)
 
+	case "doctor":
+		doctor(cfg, sshMgr)
+
+	case "explain":
+		path := "."
+		if len(os.Args) > 2 {
+			path = os.Args[2]
+		}
+		explain(cfg, path)
+
+	case "import":
+		review := len(os.Args) > 2 && os.Args[2] == "--review"
+		importAccounts(cfg, review)
+
 	default:
 		printUsage()
 		os.Exit(1)
@@ -81,12 +178,27 @@ func printUsage() {
 	fmt.Println("GitHub Multi-Account Manager CLI")
 	fmt.Println("\nUsage:")
 	fmt.Println("  ghmm-cli setup                                        # Guided setup (recommended)")
-	fmt.Println("  ghmm-cli add <name> <username> <email> <directory>   # Add account manually")
+	fmt.Println("  ghmm-cli add <name> <username> <email> <dir> [dir...] # Add account manually")
 	fmt.Println("  ghmm-cli list                                         # List all accounts")
-	fmt.Println("  ghmm-cli generate-key <name>                          # Generate SSH key")
+	fmt.Println("  ghmm-cli generate-key <name> [--type T] [--resident]  # Generate SSH key (T: ed25519, ed25519-sk, ecdsa-sk)")
+	fmt.Println("  ghmm-cli generate-signing-key <name> [path] [flags]   # Generate a separate SSH signing key")
 	fmt.Println("  ghmm-cli test <name>                                  # Test GitHub connection")
 	fmt.Println("  ghmm-cli set-default <name>                           # Set default account")
 	fmt.Println("  ghmm-cli remove <name>                                # Remove account")
+	fmt.Println("  ghmm-cli doctor                                       # Check what ssh will actually use")
+	fmt.Println("  ghmm-cli import [--review]                            # Import accounts from existing setup")
+	fmt.Println("  ghmm-cli remote-patterns <name> [pattern...|--clear]  # Select an account by remote URL")
+	fmt.Println("  ghmm-cli orgs <name> [org...|--clear]                 # Route an owner's repositories to an account")
+	fmt.Println("  ghmm-cli rewrite-urls <name> [on|off]                 # Send plain github.com URLs for its orgs through its key")
+	fmt.Println("  ghmm-cli ssh-mode <name> [alias|command]              # Pick the key by host alias or core.sshCommand")
+	fmt.Println("  ghmm-cli signing <name> [none|ssh|gpg|x509] [flags]   # Sign with --key K, --commits, --tags")
+	fmt.Println("  ghmm-cli signers [import|remove <file>]               # Show or extend ~/.ghmm/allowed_signers")
+	fmt.Println("  ghmm-cli verify [path] [-n N]                         # Check recent commits' signatures")
+	fmt.Println("  ghmm-cli token <set|get|rm> <name>                    # Keep a personal access token in ~/.ghmm/tokens.vault,")
+	fmt.Println("                                                        # unlocked by $GHMM_VAULT_PASSPHRASE or a prompt")
+	fmt.Println("  ghmm-cli credential <get|store|erase>                 # Git credential helper for HTTPS remotes")
+	fmt.Println("  ghmm-cli explain [path]                               # Show which account a directory uses")
+	fmt.Println("  ghmm-cli config migrate [--check]                     # Upgrade config file to the current version")
 	fmt.Println("\nQuick Start:")
 	fmt.Println("  ghmm-cli setup      # Guided setup - recommended for first-time users!")
 	fmt.Println("\nManual Setup:")
@@ -95,9 +207,37 @@ func printUsage() {
 	fmt.Println("  ghmm-cli test work")
 }
 
-func addAccount(cfg *config.Config, name, username, email, directory string) {
-	if err := cfg.AddAccount(name, username, email, directory); err != nil {
+// printError prints err, listing validation errors one field per line
+func printError(err error) {
+	var invalid config.ValidationErrors
+	if !errors.As(err, &invalid) {
 		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "❌ Invalid account:")
+	for _, e := range invalid {
+		fmt.Fprintf(os.Stderr, "   • %s %q: %s\n", e.Field, e.Value, e.Reason)
+	}
+}
+
+// printProblems warns about validation problems found in the config file
+func printProblems(file string, err error) {
+	var invalid config.ValidationErrors
+	if !errors.As(err, &invalid) {
+		fmt.Fprintf(os.Stderr, "⚠️  %s: %v\n", file, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "⚠️  %s has problems:\n", file)
+	for _, e := range invalid {
+		fmt.Fprintf(os.Stderr, "   • %v\n", e)
+	}
+	fmt.Fprintln(os.Stderr, "   Fix them with 'ghmm' (press 'e'), 'ghmm-cli remove' or by editing the file")
+	fmt.Fprintln(os.Stderr)
+}
+
+func addAccount(cfg *config.Config, name, username, email string, directories ...string) {
+	if err := cfg.AddAccount(name, username, email, directories...); err != nil {
+		printError(err)
 		os.Exit(1)
 	}
 	fmt.Printf("✅ Account '%s' added successfully!\n", name)
@@ -107,7 +247,47 @@ func addAccount(cfg *config.Config, name, username, email, directory string) {
 	fmt.Println("  3. Test connection: ghmm-cli test", name)
 }
 
-func generateKey(cfg *config.Config, sshMgr *ssh.Manager, name string) {
+// parseKeyFlags reads --type and --resident from args and returns the other arguments
+func parseKeyFlags(args []string) (ssh.KeyOptions, []string) {
+	var keyType string
+	var resident bool
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--type":
+			if i+1 == len(args) {
+				fmt.Fprintln(os.Stderr, "❌ --type needs a key type: ed25519, ed25519-sk or ecdsa-sk")
+				os.Exit(1)
+			}
+			i++
+			keyType = args[i]
+		case "--resident":
+			resident = true
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+
+	opts, err := ssh.ParseKeyOptions(keyType, resident)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	return opts, rest
+}
+
+// printTouchPrompt tells the user a security key is about to need them
+func printTouchPrompt(opts ssh.KeyOptions) {
+	if !opts.SecurityKey() {
+		return
+	}
+	fmt.Println("👆 Insert your security key and touch it when it blinks")
+	if opts.Resident {
+		fmt.Println("   (resident keys also ask for the security key's PIN)")
+	}
+}
+
+func generateKey(cfg *config.Config, sshMgr *ssh.Manager, name string, opts ssh.KeyOptions) {
 	accounts := cfg.ListAccounts()
 	var account *config.Account
 
@@ -123,10 +303,11 @@ func generateKey(cfg *config.Config, sshMgr *ssh.Manager, name string) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("🔑 Generating SSH key for '%s'...\n", name)
+	fmt.Printf("🔑 Generating %s SSH key for '%s'...\n", opts, name)
+	printTouchPrompt(opts)
 
 	// Generate the key
-	if err := sshMgr.GenerateKey(account.SSHKeyPath, account.Email, "ed25519"); err != nil {
+	if err := sshMgr.GenerateKey(account.SSHKeyPath, account.Email, opts); err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Failed to generate key: %v\n", err)
 		os.Exit(1)
 	}
@@ -148,8 +329,8 @@ func generateKey(cfg *config.Config, sshMgr *ssh.Manager, name string) {
 	}
 
 	fmt.Println("🚀 Next steps:")
-	fmt.Println("  1. Go to https://github.com/settings/keys")
-	fmt.Println("  2. Click 'New SSH key'")
+	fmt.Println("  1. Go to", keySettingsURL(*account))
+	fmt.Println("  2. Click 'New SSH key' and leave Key type as 'Authentication Key'")
 	fmt.Println("  3. Paste the key (already in clipboard!)")
 	fmt.Println("  4. Give it a title like:", name)
 	fmt.Println()
@@ -184,8 +365,11 @@ func testConnection(cfg *config.Config, sshMgr *ssh.Manager, name string) {
 	}
 
 	fmt.Printf("🧪 Testing SSH connection for '%s'...\n", name)
+	if ssh.IsSecurityKey(account.SSHKeyPath) {
+		fmt.Println("👆 Touch your security key when it blinks")
+	}
 
-	success, message := sshMgr.TestConnection(account.HostAlias)
+	success, message := sshMgr.TestConnection(*account)
 
 	if success {
 		fmt.Printf("✅ Success! %s\n", message)
@@ -211,6 +395,528 @@ func setDefault(cfg *config.Config, name string) {
 	fmt.Printf("✅ Default account set to '%s'\n", name)
 }
 
+// remotePatterns shows an account's remote URL patterns, or replaces them when patterns are given
+func remotePatterns(cfg *config.Config, name string, patterns []string) {
+	account, err := cfg.GetAccount(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(patterns) > 0 {
+		updated := *account
+		updated.RemotePatterns = patterns
+		if len(patterns) == 1 && patterns[0] == "--clear" {
+			updated.RemotePatterns = nil
+		}
+		if err := cfg.UpdateAccount(name, updated); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		account = &updated
+		fmt.Printf("✅ Updated remote patterns for '%s'\n", name)
+		fmt.Println("💡 Run 'ghmm' and press 'a' to apply configs")
+	}
+
+	if len(account.RemotePatterns) == 0 {
+		fmt.Printf("'%s' is selected by directory only\n", name)
+		return
+	}
+
+	fmt.Printf("\n'%s' is used for repositories whose remote matches:\n", name)
+	for _, glob := range account.RemoteURLPatterns() {
+		fmt.Printf("  • %s\n", glob)
+	}
+	fmt.Println()
+}
+
+// accountOrgs shows the repository owners routed to an account, or replaces them when orgs are given
+func accountOrgs(cfg *config.Config, name string, orgs []string) {
+	account, err := cfg.GetAccount(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(orgs) > 0 {
+		updated := *account
+		updated.Orgs = orgs
+		if len(orgs) == 1 && orgs[0] == "--clear" {
+			updated.Orgs = nil
+		}
+		if err := cfg.UpdateAccount(name, updated); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		account = &updated
+		fmt.Printf("✅ Updated orgs for '%s'\n", name)
+		fmt.Println("💡 Run 'ghmm' and press 'a' to apply configs")
+	}
+
+	if len(account.Orgs) == 0 {
+		fmt.Printf("'%s' is picked for clones by directory only\n", name)
+		return
+	}
+
+	owners := config.OrgOwners(cfg.ListAccounts())
+	fmt.Printf("\nRepositories owned by these are cloned with '%s':\n", name)
+	for _, org := range account.Orgs {
+		fmt.Printf("  • %s\n", org)
+		if claimants := owners[strings.ToLower(org)]; len(claimants) > 1 {
+			var others []string
+			for _, claimant := range claimants {
+				if claimant.Name != name {
+					others = append(others, claimant.Name)
+				}
+			}
+			fmt.Printf("    ⚠️  also listed by %s; the directory decides between them\n", strings.Join(others, ", "))
+		}
+	}
+	fmt.Println()
+}
+
+// rewriteURLs shows the insteadOf rules for an account, or turns them on or off
+func rewriteURLs(cfg *config.Config, name string, args []string) {
+	account, err := cfg.GetAccount(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		updated := *account
+		switch args[0] {
+		case "on":
+			updated.RewriteURLs = true
+		case "off":
+			updated.RewriteURLs = false
+		default:
+			fmt.Println("Usage: ghmm-cli rewrite-urls <account-name> [on|off]")
+			os.Exit(1)
+		}
+		if err := cfg.UpdateAccount(name, updated); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		account = &updated
+		fmt.Printf("✅ URL rewriting %s for '%s'\n", args[0], name)
+		fmt.Println("💡 Run 'ghmm' and press 'a' to apply configs")
+	}
+
+	if !account.RewriteURLs {
+		fmt.Printf("'%s' only uses its key for URLs with the %s host alias\n", name, account.HostAlias)
+		return
+	}
+	if len(account.Orgs) == 0 {
+		fmt.Printf("⚠️  '%s' has no orgs, so nothing is rewritten. Add some with: ghmm-cli orgs %s <org>\n", name, name)
+		return
+	}
+
+	rewrites, ambiguous := config.URLRewrites(cfg.ListAccounts())
+	fmt.Printf("\nURLs rewritten to use '%s':\n", name)
+	for _, rewrite := range rewrites {
+		if rewrite.Account.Name != name {
+			continue
+		}
+		for _, prefix := range rewrite.InsteadOf {
+			fmt.Printf("  • %s → %s\n", prefix, rewrite.Base)
+		}
+	}
+	for _, org := range ambiguous {
+		if slices.ContainsFunc(account.Orgs, func(o string) bool { return strings.EqualFold(o, org) }) {
+			fmt.Printf("  ⚠️  %s is listed by several accounts, so it is not rewritten\n", org)
+		}
+	}
+	fmt.Println()
+}
+
+// sshMode shows how git picks an account's key, or switches between a host alias and core.sshCommand
+func sshMode(cfg *config.Config, name string, args []string) {
+	account, err := cfg.GetAccount(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		updated := *account
+		updated.SSHMode = args[0]
+		if args[0] == config.SSHModeAlias {
+			updated.SSHMode = ""
+		}
+		if err := cfg.UpdateAccount(name, updated); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		account = &updated
+		fmt.Printf("✅ '%s' now uses %s\n", name, account.SSHModeDescription())
+		fmt.Println("💡 Run 'ghmm' and press 'a' to apply configs")
+	}
+
+	if !account.UsesSSHCommand() {
+		fmt.Printf("'%s' uses the %s host alias from ~/.ssh/config; remotes look like git@%s:owner/repo.git\n",
+			name, account.HostAlias, account.HostAlias)
+		return
+	}
+	fmt.Printf("'%s' sets core.sshCommand in ~/.gitconfig-%s:\n", name, name)
+	fmt.Printf("  %s\n", account.SSHCommand())
+	fmt.Printf("Remotes keep the plain host, e.g. git@%s:owner/repo.git. Only repositories matched\n", account.GitHubHost())
+	fmt.Println("by the account's directories or remote patterns pick up the key; gclone passes it when cloning")
+}
+
+// keySettingsURL returns the page where SSH keys are added on the account's host
+func keySettingsURL(account config.Account) string {
+	return "https://" + account.GitHubHost() + "/settings/keys"
+}
+
+func generateSigningKey(cfg *config.Config, sshMgr *ssh.Manager, name string, args []string, opts ssh.KeyOptions) {
+	account, err := cfg.GetAccount(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	updated := *account
+	updated.SigningKeyPath = account.DefaultSigningKeyPath()
+	if len(args) > 0 {
+		updated.SigningKeyPath = config.ExpandHome(args[0])
+	}
+
+	if _, err := os.Stat(updated.SigningKeyPath); err == nil {
+		fmt.Printf("🔑 Using existing signing key %s\n", updated.SigningKeyPath)
+	} else {
+		fmt.Printf("🔑 Generating %s SSH signing key for '%s'...\n", opts, name)
+		printTouchPrompt(opts)
+		if err := sshMgr.GenerateKey(updated.SigningKeyPath, account.Email, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to generate key: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Signing key generated: %s\n", updated.SigningKeyPath)
+	}
+
+	if err := cfg.UpdateAccount(name, updated); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
+
+	pubKey, err := sshMgr.GetPublicKey(updated.SigningKeyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to read public key: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := clipboard.WriteAll(pubKey); err != nil {
+		fmt.Printf("⚠️  Could not copy to clipboard, but here's your public key:\n\n%s\n\n", pubKey)
+	} else {
+		fmt.Println("📋 Public key copied to clipboard!")
+		fmt.Printf("\n%s\n\n", pubKey)
+	}
+
+	fmt.Println("🚀 Next steps:")
+	fmt.Println("  1. Go to", keySettingsURL(updated))
+	fmt.Println("  2. Click 'New SSH key' and set Key type to 'Signing Key'")
+	fmt.Println("     (GitHub keeps signing keys apart from the 'Authentication Key' used to push)")
+	fmt.Println("  3. Paste the key (already in clipboard!)")
+	fmt.Println("  4. Give it a title like:", name+" signing")
+	if updated.SigningMode() != config.SigningSSH {
+		fmt.Printf("\n⚠️  '%s' signs with %s; run 'ghmm-cli signing %s ssh' to use this key\n", name, updated.SigningMode(), name)
+	} else if updated.Signing.Key != "" {
+		fmt.Printf("\n⚠️  signing.key is set to %s and takes precedence; run 'ghmm-cli signing %s ssh' to clear it\n", updated.Signing.Key, name)
+	}
+	fmt.Println("\n💡 Run 'ghmm' and press 'a' to apply configs")
+}
+
+// signing shows an account's signing setup, or replaces it when a mode is given
+func signing(cfg *config.Config, name string, args []string) {
+	account, err := cfg.GetAccount(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		updated := *account
+		updated.Signing = config.Signing{Mode: args[0]}
+		for i := 1; i < len(args); i++ {
+			switch args[i] {
+			case "--commits":
+				updated.Signing.SignCommits = true
+			case "--tags":
+				updated.Signing.SignTags = true
+			case "--key":
+				if i+1 == len(args) {
+					fmt.Println("Usage: ghmm-cli signing <account-name> [none|ssh|gpg|x509] [--key <key>] [--commits] [--tags]")
+					os.Exit(1)
+				}
+				i++
+				updated.Signing.Key = config.ExpandHome(args[i])
+			default:
+				fmt.Fprintf(os.Stderr, "❌ Unknown option %s\n", args[i])
+				os.Exit(1)
+			}
+		}
+		if err := cfg.UpdateAccount(name, updated); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		account = &updated
+		fmt.Printf("✅ Updated signing for '%s'\n", name)
+		fmt.Println("💡 Run 'ghmm' and press 'a' to apply configs")
+	}
+
+	fmt.Printf("'%s' signing: %s\n", name, account.SigningDescription())
+}
+
+// signers shows the generated allowed_signers file, or imports or removes a team signers file
+func signers(cfg *config.Config, args []string) {
+	if len(args) > 0 {
+		if len(args) != 2 || (args[0] != "import" && args[0] != "remove") {
+			fmt.Println("Usage: ghmm-cli signers [import|remove <allowed-signers-file>]")
+			os.Exit(1)
+		}
+
+		var err error
+		message := fmt.Sprintf("✅ Stopped merging signers from %s", args[1])
+		if args[0] == "import" {
+			message = fmt.Sprintf("✅ Signers from %s will be merged into %s", args[1], cfg.AllowedSignersFile())
+			if _, err = git.ParseSignersFile(config.ExpandHome(args[1])); err == nil {
+				err = cfg.AddSignerFile(args[1])
+			}
+		} else {
+			err = cfg.RemoveSignerFile(args[1])
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(message)
+		fmt.Println("💡 Run 'ghmm' and press 'a' to apply configs")
+		return
+	}
+
+	gitMgr, err := git.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s (as of the next apply):\n\n", cfg.AllowedSignersFile())
+	fmt.Print(gitMgr.GenerateAllowedSigners(cfg.ListAccounts(), cfg.SignerFiles))
+}
+
+// verify checks the signatures of a repository's recent commits against the allowed_signers file
+func verify(cfg *config.Config, args []string) {
+	path, count := ".", 20
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-n" && i+1 < len(args) {
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "❌ -n needs a positive number, got %q\n", args[i+1])
+				os.Exit(1)
+			}
+			count = n
+			i++
+			continue
+		}
+		path = args[i]
+	}
+
+	allowedSigners := cfg.AllowedSignersFile()
+	if _, err := os.Stat(allowedSigners); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s does not exist yet; run 'ghmm' and press 'a' to apply configs\n", allowedSigners)
+		os.Exit(1)
+	}
+
+	signatures, err := git.VerifyCommits(path, allowedSigners, count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔏 Last %d commits in %s, checked against %s\n\n", len(signatures), path, allowedSigners)
+	bad := 0
+	for _, sig := range signatures {
+		var note string
+		switch {
+		case sig.Good():
+			note = "✅ signed by " + sig.Signer
+			if !strings.EqualFold(sig.Signer, sig.Author) {
+				note = fmt.Sprintf("⚠️  signed by %s but authored by %s", sig.Signer, sig.Author)
+			}
+		case sig.Status == "U":
+			note = "⚠️  good signature, but the key isn't in allowed_signers"
+		case sig.Status == "N":
+			note = "·  not signed"
+		case sig.Status == "B":
+			note = "❌ bad signature"
+			bad++
+		case sig.Status == "X" || sig.Status == "Y":
+			note = "⚠️  signed with an expired key"
+		case sig.Status == "R":
+			note = "❌ signed with a revoked key"
+			bad++
+		default:
+			note = "⚠️  signature can't be checked"
+		}
+		fmt.Printf("  %s %-50.50s %s\n", sig.Hash[:8], sig.Subject, note)
+	}
+	fmt.Println()
+
+	if bad > 0 {
+		fmt.Printf("❌ %d commit(s) have bad signatures\n", bad)
+		os.Exit(1)
+	}
+}
+
+// credential answers git's credential helper protocol on stdin and stdout.
+// get returns the username and token of the account that owns the
+// repository, picked by owner then directory, from the vault or else gh;
+// store and erase are ignored because tokens are managed with ghmm-cli token
+// and gh. Messages go to stderr, which git shows.
+func credential(cfg *config.Config, action string) {
+	request, err := git.ReadCredential(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ghmm: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "get":
+	case "store", "erase":
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "ghmm: unknown credential action %q\n", action)
+		os.Exit(1)
+	}
+
+	if request.Protocol != "https" {
+		return
+	}
+
+	cwd, _ := os.Getwd()
+	route := cfg.RouteRepository(request.Owner(), cwd)
+	account := route.Account
+	// Leave other hosts, and URLs naming a different user, to git's other helpers
+	if account == nil || !strings.EqualFold(account.GitHubHost(), request.Host) {
+		return
+	}
+	if request.Username != "" && !strings.EqualFold(request.Username, account.Username) {
+		return
+	}
+
+	answer := git.Credential{Username: account.Username}
+	// Without a terminal or $GHMM_VAULT_PASSPHRASE the vault can't be unlocked,
+	// as when an IDE runs git; go straight to gh rather than warn on every fetch
+	if token, err := vaultToken(cfg, account.Name); err != nil {
+		if !errors.Is(err, secrets.ErrNoTerminal) {
+			fmt.Fprintf(os.Stderr, "ghmm: %v; trying gh\n", err)
+		}
+	} else {
+		answer.Password = token
+	}
+	if answer.Password == "" {
+		token, err := git.GHToken(account.GitHubHost(), account.Username)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ghmm: %v; run 'gh auth login --hostname %s' as %s or 'ghmm-cli token set %s'\n",
+				err, account.GitHubHost(), account.Username, account.Name)
+		} else {
+			answer.Password = token
+		}
+	}
+
+	if err := git.WriteCredential(os.Stdout, answer); err != nil {
+		fmt.Fprintf(os.Stderr, "ghmm: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// vaultToken returns the account's token from the vault, or "" when it has
+// none, asking for the passphrase only when there is one to unlock
+func vaultToken(cfg *config.Config, name string) (string, error) {
+	stored, err := secrets.StoredAccounts(cfg.VaultFile())
+	if err != nil || !slices.Contains(stored, name) {
+		return "", err
+	}
+
+	passphrase, err := secrets.Passphrase(false)
+	if err != nil {
+		return "", err
+	}
+	vault, err := secrets.Open(cfg.VaultFile(), passphrase)
+	if err != nil {
+		return "", err
+	}
+	return vault.Get(name)
+}
+
+// token stores, prints or removes an account's personal access token in the
+// encrypted vault. Tokens are read from stdin or a hidden prompt, never argv.
+func token(cfg *config.Config, action, name string) {
+	if _, err := cfg.GetAccount(name); err != nil && action != "rm" {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var value string
+	switch action {
+	case "set":
+		var err error
+		value, err = secrets.ReadToken(fmt.Sprintf("Token for '%s': ", name))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		if value == "" {
+			fmt.Fprintln(os.Stderr, "❌ No token given")
+			os.Exit(1)
+		}
+	case "get", "rm":
+	default:
+		fmt.Println("Usage: ghmm-cli token <set|get|rm> <account-name>")
+		fmt.Printf("The vault passphrase is read from $%s, or asked for on the terminal\n", secrets.PassphraseEnv)
+		os.Exit(1)
+	}
+
+	path := cfg.VaultFile()
+	if action != "set" && !secrets.Exists(path) {
+		fmt.Fprintf(os.Stderr, "❌ No tokens stored yet; add one with: ghmm-cli token set %s\n", name)
+		os.Exit(1)
+	}
+
+	// A new vault asks for its passphrase twice
+	passphrase, err := secrets.Passphrase(!secrets.Exists(path))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	vault, err := secrets.Open(path, passphrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "set":
+		err = vault.Set(name, value)
+		if err == nil {
+			fmt.Printf("✅ Token for '%s' saved in %s\n", name, path)
+		}
+	case "get":
+		value, err = vault.Get(name)
+		if err == nil {
+			fmt.Println(value)
+		}
+	case "rm":
+		err = vault.Remove(name)
+		if err == nil {
+			fmt.Printf("✅ Token for '%s' removed\n", name)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func setupWizard(cfg *config.Config, sshMgr *ssh.Manager) {
 	fmt.Println("🚀 GitHub Multi-Account Manager - Setup Wizard")
 	fmt.Println()
@@ -263,7 +969,7 @@ func setupWizard(cfg *config.Config, sshMgr *ssh.Manager) {
 		// Add the account
 		fmt.Printf("✓ Creating account '%s'...\n", name)
 		if err := cfg.AddAccount(name, username, email, directory); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			printError(err)
 			continue
 		}
 
@@ -279,7 +985,7 @@ func setupWizard(cfg *config.Config, sshMgr *ssh.Manager) {
 
 		// Generate SSH key
 		fmt.Printf("✓ Generating SSH key...\n")
-		if err := sshMgr.GenerateKey(account.SSHKeyPath, account.Email, "ed25519"); err != nil {
+		if err := sshMgr.GenerateKey(account.SSHKeyPath, account.Email, ssh.KeyOptions{}); err != nil {
 			fmt.Fprintf(os.Stderr, "❌ Failed to generate key: %v\n", err)
 			fmt.Println("You can generate it later with: ghmm-cli generate-key", name)
 		} else {
@@ -305,8 +1011,8 @@ func setupWizard(cfg *config.Config, sshMgr *ssh.Manager) {
 				fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 				fmt.Println("🔑 Next: Add this key to GitHub")
 				fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-				fmt.Println("  1. Go to: https://github.com/settings/keys")
-				fmt.Println("  2. Click 'New SSH key'")
+				fmt.Println("  1. Go to:", keySettingsURL(*account))
+				fmt.Println("  2. Click 'New SSH key' and leave Key type as 'Authentication Key'")
 				fmt.Println("  3. Title:", name, "(or any name you like)")
 				fmt.Println("  4. Paste the key above (already in clipboard!)")
 				fmt.Println("  5. Click 'Add SSH key'")
@@ -327,11 +1033,11 @@ func setupWizard(cfg *config.Config, sshMgr *ssh.Manager) {
 
 					// Test connection
 					fmt.Printf("🧪 Testing connection for '%s'...\n", name)
-					success, message := sshMgr.TestConnection(account.HostAlias)
+					success, message := sshMgr.TestConnection(*account)
 
 					if success {
 						fmt.Printf("✅ Success! %s\n\n", message)
-						fmt.Println("🎉 Account '%s' is fully configured and ready!\n", name)
+						fmt.Printf("🎉 Account '%s' is fully configured and ready!\n\n", name)
 					} else {
 						fmt.Printf("❌ Connection failed: %s\n\n", message)
 						fmt.Println("🔍 Troubleshooting:")
@@ -385,7 +1091,10 @@ func listAccounts(cfg *config.Config) {
 		return
 	}
 
-	fmt.Println("\n📦 GitHub Accounts:\n")
+	// Names in the vault are readable without the passphrase
+	tokens, _ := secrets.StoredAccounts(cfg.VaultFile())
+
+	fmt.Print("\n📦 GitHub Accounts:\n\n")
 
 	for _, acc := range accounts {
 		isDefault := acc.Name == defaultAcc
@@ -397,8 +1106,16 @@ func listAccounts(cfg *config.Config) {
 		fmt.Printf("%s %s\n", prefix, acc.Name)
 		fmt.Printf("     Username:  %s\n", acc.Username)
 		fmt.Printf("     Email:     %s\n", acc.Email)
-		fmt.Printf("     Directory: %s\n", acc.Directory)
+		fmt.Printf("     Directory: %s\n", strings.Join(acc.Directories, ", "))
 		fmt.Printf("     SSH Key:   %s\n", acc.SSHKeyPath)
+		if acc.HasSeparateSigningKey() {
+			fmt.Printf("     Sign Key:  %s\n", acc.SigningKeyPath)
+		}
+		fmt.Printf("     SSH Mode:  %s\n", acc.SSHModeDescription())
+		fmt.Printf("     Signing:   %s\n", acc.SigningDescription())
+		if slices.Contains(tokens, acc.Name) {
+			fmt.Println("     Token:     stored in vault")
+		}
 		fmt.Println()
 	}
 }
@@ -410,3 +1127,266 @@ func removeAccount(cfg *config.Config, name string) {
 	}
 	fmt.Printf("✅ Account '%s' removed\n", name)
 }
+
+func doctor(cfg *config.Config, sshMgr *ssh.Manager) {
+	accounts := cfg.ListAccounts()
+	if len(accounts) == 0 {
+		fmt.Println("No accounts configured yet.")
+		return
+	}
+
+	problems, err := sshMgr.CheckAccounts(accounts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print("\n🩺 SSH Config Check:\n\n")
+
+	failed := false
+	for _, acc := range accounts {
+		var accountProblems []ssh.Problem
+		for _, p := range problems {
+			if p.Account == acc.Name {
+				accountProblems = append(accountProblems, p)
+			}
+		}
+
+		if len(accountProblems) == 0 {
+			fmt.Printf("✅ %s (%s)\n", acc.Name, acc.SSHModeDescription())
+			continue
+		}
+
+		fmt.Printf("⚠️  %s (%s)\n", acc.Name, acc.SSHModeDescription())
+		for _, p := range accountProblems {
+			icon := "⚠️ "
+			if p.Severity == ssh.SeverityError {
+				icon = "❌"
+				failed = true
+			}
+			fmt.Printf("     %s %s\n", icon, p.Message)
+		}
+	}
+	fmt.Println()
+
+	if failed {
+		fmt.Println("💡 Run 'ghmm' and press 'a' to apply configs, then fix any entries listed above")
+		os.Exit(1)
+	}
+}
+
+func importAccounts(cfg *config.Config, review bool) {
+	report, err := cfg.DetectionReport()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(report.Candidates) == 0 {
+		fmt.Println("No accounts found in .gitconfig or .ssh/config")
+		return
+	}
+
+	fmt.Print("\n🔍 Detected Accounts:\n\n")
+
+	var accepted []config.Account
+	for _, candidate := range report.Candidates {
+		printCandidate(candidate)
+
+		if candidate.Exists {
+			fmt.Println("     ⏭️  Already configured, skipping")
+			fmt.Println()
+			continue
+		}
+
+		if !review {
+			if candidate.ReviewOnly() {
+				fmt.Println("     ⏭️  No email detected; run 'ghmm-cli import --review' to enter one")
+			} else {
+				accepted = append(accepted, candidate.Account)
+			}
+			fmt.Println()
+			continue
+		}
+
+		if candidate.Account.Username == "" {
+			fmt.Print("     GitHub username (not detected): ")
+			fmt.Scanln(&candidate.Account.Username)
+		}
+		if candidate.Account.Email == "" {
+			fmt.Print("     Commit email (not detected): ")
+			fmt.Scanln(&candidate.Account.Email)
+		}
+
+		fmt.Print("   [a]ccept, [e]dit or [r]eject? (a): ")
+		var response string
+		fmt.Scanln(&response)
+
+		switch response {
+		case "r", "R", "reject":
+			fmt.Println("     ✗ Rejected")
+		case "e", "E", "edit":
+			account := editCandidate(candidate.Account)
+			accepted = append(accepted, account)
+			fmt.Printf("     ✓ Accepted as '%s'\n", account.Name)
+		default:
+			accepted = append(accepted, candidate.Account)
+			fmt.Println("     ✓ Accepted")
+		}
+		fmt.Println()
+	}
+
+	for alias, key := range report.UnpairedAliases {
+		fmt.Printf("ℹ️  SSH alias %s (%s) did not match any account\n", alias, key)
+	}
+
+	imported, err := cfg.ImportAccounts(accepted)
+	var invalid config.ValidationErrors
+	if errors.As(err, &invalid) {
+		fmt.Println()
+		for _, e := range invalid {
+			fmt.Printf("⚠️  Skipped: %v\n", e)
+		}
+		fmt.Println("💡 Run 'ghmm-cli import --review' to edit skipped accounts")
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n✅ Imported %d account(s)\n", imported)
+	if len(invalid) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printCandidate(candidate config.Candidate) {
+	acc := candidate.Account
+	fmt.Printf("  %s\n", acc.Name)
+	username := acc.Username
+	if username == "" {
+		username = "(not detected)"
+	}
+	email := acc.Email
+	if email == "" {
+		email = "(not detected)"
+	}
+	fmt.Printf("     Username:  %s\n", username)
+	fmt.Printf("     Email:     %s\n", email)
+	fmt.Printf("     Directory: %s\n", strings.Join(acc.Directories, ", "))
+	if acc.Host != "" || acc.GitProtocol != "" {
+		fmt.Printf("     Host:      %s (%s)\n", acc.GitHubHost(), acc.GitProtocol)
+	}
+	fmt.Printf("     Found via: %s\n", candidate.Source)
+	if candidate.Rule == "" {
+		fmt.Println("     SSH:       no alias matched; a new key and alias will be set up")
+	} else {
+		fmt.Printf("     SSH:       %s (%s)\n", acc.HostAlias, acc.SSHKeyPath)
+		fmt.Printf("     Matched:   %s, %d%% confidence\n", candidate.Rule, candidate.Confidence)
+	}
+}
+
+// editCandidate prompts for each field, keeping the detected value on empty input
+func editCandidate(account config.Account) config.Account {
+	prompt := func(label string, value *string) {
+		fmt.Printf("     %s (%s): ", label, *value)
+		var input string
+		fmt.Scanln(&input)
+		if input != "" {
+			*value = input
+		}
+	}
+
+	prompt("Account name", &account.Name)
+	prompt("GitHub username", &account.Username)
+	prompt("Email", &account.Email)
+	directories := strings.Join(account.Directories, ",")
+	prompt("Directories, comma separated", &directories)
+	prompt("Host alias", &account.HostAlias)
+	prompt("SSH key", &account.SSHKeyPath)
+	account.Directories = config.ExpandDirectories(strings.Split(directories, ","))
+	return account
+}
+
+func configCommand(args []string) {
+	if len(args) == 0 || args[0] != "migrate" {
+		fmt.Println("Usage: ghmm-cli config migrate [--check]")
+		os.Exit(1)
+	}
+	check := len(args) > 1 && args[1] == "--check"
+
+	status, err := config.CheckMigrations("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(status.Pending) == 0 {
+		fmt.Printf("✅ %s is up to date (version %d)\n", status.File, status.Version)
+		return
+	}
+
+	if check {
+		fmt.Printf("⚠️  %s is at version %d, current is %d. Pending migrations:\n", status.File, status.Version, config.CurrentVersion)
+		for _, m := range status.Pending {
+			fmt.Printf("  • %s\n", m)
+		}
+		fmt.Println("\n💡 Run 'ghmm-cli config migrate' to apply them")
+		os.Exit(1)
+	}
+
+	cfg, err := config.New("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, m := range cfg.Migrated() {
+		fmt.Printf("  ✓ %s\n", m)
+	}
+	fmt.Printf("✅ Migrated %s to version %d\n", cfg.ConfigFile(), config.CurrentVersion)
+	fmt.Printf("💾 Previous version saved to %s\n", cfg.BackupFile())
+}
+
+func explain(cfg *config.Config, path string) {
+	resolution := cfg.Resolve(path)
+
+	fmt.Printf("\n📍 %s\n\n", resolution.Path)
+
+	switch {
+	case resolution.Account == nil:
+		fmt.Println("   No account's directory contains this path and no default account is set")
+		fmt.Println("   git will use your global user.name/user.email")
+	case resolution.RemoteURL != "":
+		winner := resolution.Account
+		fmt.Printf("   ✅ %s (by remote URL)\n", winner.Name)
+		fmt.Printf("      Remote:   %s\n", resolution.RemoteURL)
+		fmt.Printf("      Pattern:  %s\n", resolution.RemotePattern)
+		fmt.Printf("      Email:    %s\n", winner.Email)
+		for _, other := range resolution.Matches {
+			fmt.Printf("   ↳ overrides %s (%s); remote URL rules win over directories\n", other.Account.Name, other.Directory)
+		}
+	case resolution.Default:
+		fmt.Println("   No account's directory or remote pattern matches this path, so git uses")
+		fmt.Println("   your global user.name/user.email")
+		fmt.Printf("   👤 Only gclone falls back to the default account, %s\n", resolution.Account.Name)
+	default:
+		winner := resolution.Account
+		fmt.Printf("   ✅ %s (%s)\n", winner.Name, resolution.Matches[0].Directory)
+		fmt.Printf("      Email:    %s\n", winner.Email)
+		fmt.Printf("      SSH host: %s\n", winner.SSHHost())
+		for _, other := range resolution.Matches[1:] {
+			fmt.Printf("   ↳ overrides %s (%s), whose directory also contains this path\n", other.Account.Name, other.Directory)
+		}
+		if len(resolution.Matches) > 1 {
+			fmt.Println("\n   The most specific directory wins: its includeIf is written last in")
+			fmt.Println("   ~/.gitconfig, and gclone checks it first")
+		}
+	}
+
+	if overlaps := cfg.Overlaps(); len(overlaps) > 0 {
+		fmt.Println("\n📂 Nested account directories:")
+		for _, o := range overlaps {
+			fmt.Printf("   • %s takes precedence over %s inside %s\n", o.Inner.Account.Name, o.Outer.Account.Name, o.Inner.Directory)
+		}
+	}
+	fmt.Println()
+}
@@ -4,36 +4,134 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/donbowman/github-multi-account-manager/internal/secrets"
 	"gopkg.in/yaml.v3"
 )
 
 // Account represents a GitHub account configuration
 type Account struct {
-	Name       string `yaml:"name"`
-	Username   string `yaml:"username"`
-	Email      string `yaml:"email"`
-	Directory  string `yaml:"directory"`
-	SSHKeyPath string `yaml:"ssh_key_path"`
-	HostAlias  string `yaml:"host_alias"`
+	Name        string   `yaml:"name"`
+	Username    string   `yaml:"username"`
+	Email       string   `yaml:"email"`
+	Directories []string `yaml:"directories"`
+	SSHKeyPath  string   `yaml:"ssh_key_path"`
+	HostAlias   string   `yaml:"host_alias"`
+	Host        string   `yaml:"host,omitempty"`         // GitHub host, empty for github.com
+	GitProtocol string   `yaml:"git_protocol,omitempty"` // ssh or https, as configured in gh
+	// SigningKeyPath is a private key used only for SSH signing, kept apart
+	// from the authentication key at SSHKeyPath
+	SigningKeyPath string `yaml:"signing_key_path,omitempty"`
+	// RemotePatterns select the account by remote URL, e.g. github.com/acme-corp/**
+	RemotePatterns []string `yaml:"remote_patterns,omitempty"`
+	// Orgs are repository owners that always use this account, wherever they're cloned
+	Orgs []string `yaml:"orgs,omitempty"`
+	// RewriteURLs sends plain host URLs for the account's orgs through its host
+	// alias with url.<alias>.insteadOf rules in ~/.gitconfig
+	RewriteURLs bool `yaml:"rewrite_urls,omitempty"`
+	// SSHMode is how git picks the account's key: a host alias (the default) or core.sshCommand
+	SSHMode string  `yaml:"ssh_mode,omitempty"`
+	Signing Signing `yaml:"signing,omitempty"`
+}
+
+// PrimaryDirectory returns the account's first directory, used where a
+// single location is needed such as the default clone destination
+func (a Account) PrimaryDirectory() string {
+	if len(a.Directories) == 0 {
+		return ""
+	}
+	return a.Directories[0]
+}
+
+// ExpandDirectories expands ~/ in each directory and drops empty entries
+func ExpandDirectories(directories []string) []string {
+	expanded := make([]string, 0, len(directories))
+	for _, dir := range directories {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			expanded = append(expanded, ExpandHome(dir))
+		}
+	}
+	return expanded
+}
+
+// DefaultHost is the GitHub host used when an account doesn't set one
+const DefaultHost = "github.com"
+
+// GitHubHost returns the host the account lives on
+func (a Account) GitHubHost() string {
+	if a.Host != "" {
+		return a.Host
+	}
+	return DefaultHost
+}
+
+// SSH modes for Account.SSHMode
+const (
+	// SSHModeAlias routes remotes through a ~/.ssh/config Host alias
+	SSHModeAlias = "alias"
+	// SSHModeCommand sets core.sshCommand in the account's gitconfig, so
+	// remotes keep the plain host and no ssh config block is needed
+	SSHModeCommand = "command"
+)
+
+// UsesSSHCommand reports whether the account picks its key with core.sshCommand
+func (a Account) UsesSSHCommand() bool {
+	return a.SSHMode == SSHModeCommand
+}
+
+// UsesHTTPS reports whether the account's remotes use HTTPS, which registers
+// ghmm as git's credential helper for its host
+func (a Account) UsesHTTPS() bool {
+	return a.GitProtocol == "https"
+}
+
+// SSHCommand returns the core.sshCommand that makes ssh offer only the account's key
+func (a Account) SSHCommand() string {
+	return "ssh -i " + a.SSHKeyPath + " -o IdentitiesOnly=yes"
+}
+
+// SSHHost returns the host the account's remotes use: its host alias, or the
+// plain GitHub host when it uses core.sshCommand
+func (a Account) SSHHost() string {
+	if a.UsesSSHCommand() {
+		return a.GitHubHost()
+	}
+	return a.HostAlias
+}
+
+// SSHModeDescription describes how git picks the account's key, for display
+func (a Account) SSHModeDescription() string {
+	if a.UsesSSHCommand() {
+		return "core.sshCommand (plain " + a.GitHubHost() + " remotes)"
+	}
+	return "host alias " + a.HostAlias
 }
 
 // Config manages ghmm configuration
 type Config struct {
+	Version        int       `yaml:"version"`
 	Accounts       []Account `yaml:"accounts"`
 	DefaultAccount string    `yaml:"default_account,omitempty"`
-	configDir      string
-	configFile     string
+	// ScanRoots are searched for repositories during detection, DefaultScanRoots when empty
+	ScanRoots []string `yaml:"scan_roots,omitempty"`
+	// SignerFiles are allowed_signers files, e.g. a team's, merged into the generated one
+	SignerFiles []string `yaml:"signer_files,omitempty"`
+	configDir   string
+	configFile  string
+	migrated    []string // Migrations applied on load
+	original    []byte   // File contents from before migrating, backed up on save
+	fromVersion int      // Schema version of original
+	problems    error    // Validation problems found on load
+	backupFile  string   // Copy of the file from before migrating
 }
 
 // New creates a new Config instance
 func New(configDir string) (*Config, error) {
-	if configDir == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		configDir = filepath.Join(home, ".ghmm")
+	configDir, err := resolveConfigDir(configDir)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create config directory if it doesn't exist
@@ -53,11 +151,24 @@ func New(configDir string) (*Config, error) {
 	return c, nil
 }
 
-// load reads the configuration from file
+// resolveConfigDir defaults an empty config directory to ~/.ghmm
+func resolveConfigDir(configDir string) (string, error) {
+	if configDir != "" {
+		return configDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".ghmm"), nil
+}
+
+// load reads the configuration from file, migrating older schema versions
 func (c *Config) load() error {
 	data, err := os.ReadFile(c.configFile)
 	if os.IsNotExist(err) {
 		// Initialize with empty config
+		c.Version = CurrentVersion
 		c.Accounts = []Account{}
 		c.DefaultAccount = ""
 		return c.save()
@@ -66,15 +177,31 @@ func (c *Config) load() error {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	data, err = c.migrate(data)
+	if err != nil {
+		return err
+	}
+
 	if err := yaml.Unmarshal(data, c); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// A hand-edited file may not validate; load it anyway so the accounts can
+	// still be listed, edited and removed, and report the problems instead
+	c.problems = c.Validate()
+
+	if len(c.migrated) > 0 {
+		if err := c.backUp(); err != nil {
+			return err
+		}
+		return c.save()
+	}
 	return nil
 }
 
 // save writes the configuration to file
 func (c *Config) save() error {
+	c.Version = CurrentVersion
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
@@ -84,11 +211,12 @@ func (c *Config) save() error {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	c.problems = c.Validate()
 	return nil
 }
 
-// AddAccount adds a new GitHub account
-func (c *Config) AddAccount(name, username, email, directory string) error {
+// AddAccount adds a new GitHub account that owns the given directories
+func (c *Config) AddAccount(name, username, email string, directories ...string) error {
 	// Check if account already exists
 	for _, acc := range c.Accounts {
 		if acc.Name == name {
@@ -101,21 +229,20 @@ func (c *Config) AddAccount(name, username, email, directory string) error {
 		return fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	// Expand ~ in directory path
-	if directory[:2] == "~/" {
-		directory = filepath.Join(home, directory[2:])
-	}
-
 	account := Account{
-		Name:       name,
-		Username:   username,
-		Email:      email,
-		Directory:  directory,
-		SSHKeyPath: filepath.Join(home, ".ssh", name+"_ssh"),
-		HostAlias:  "github.com-" + name,
+		Name:        name,
+		Username:    username,
+		Email:       email,
+		Directories: ExpandDirectories(directories),
+		SSHKeyPath:  filepath.Join(home, ".ssh", name+"_ssh"),
+		HostAlias:   DefaultHost + "-" + name,
 	}
 
-	c.Accounts = append(c.Accounts, account)
+	accounts := append(slices.Clone(c.Accounts), account)
+	if err := validateChange(accounts, c.DefaultAccount, name); err != nil {
+		return err
+	}
+	c.Accounts = accounts
 
 	// Set as default if it's the first account
 	if len(c.Accounts) == 1 {
@@ -125,6 +252,42 @@ func (c *Config) AddAccount(name, username, email, directory string) error {
 	return c.save()
 }
 
+// UpdateAccount replaces the account called name with the given account.
+// Renaming an account also moves the default to the new name; its vault
+// token is left to the caller, since moving it needs the passphrase.
+func (c *Config) UpdateAccount(name string, account Account) error {
+	index := -1
+	for i, acc := range c.Accounts {
+		if acc.Name == name {
+			index = i
+		} else if acc.Name == account.Name {
+			return fmt.Errorf("account '%s' already exists", account.Name)
+		}
+	}
+
+	if index == -1 {
+		return fmt.Errorf("account '%s' not found", name)
+	}
+
+	account.Directories = ExpandDirectories(account.Directories)
+
+	accounts := slices.Clone(c.Accounts)
+	accounts[index] = account
+
+	defaultAccount := c.DefaultAccount
+	if defaultAccount == name {
+		defaultAccount = account.Name
+	}
+
+	if err := validateChange(accounts, defaultAccount, account.Name); err != nil {
+		return err
+	}
+	c.Accounts = accounts
+	c.DefaultAccount = defaultAccount
+
+	return c.save()
+}
+
 // RemoveAccount removes a GitHub account
 func (c *Config) RemoveAccount(name string) error {
 	originalLen := len(c.Accounts)
@@ -151,7 +314,19 @@ func (c *Config) RemoveAccount(name string) error {
 		}
 	}
 
-	return c.save()
+	if err := c.save(); err != nil {
+		return err
+	}
+	if err := secrets.RemoveEntry(c.VaultFile(), name); err != nil {
+		return fmt.Errorf("failed to remove token for '%s' from the vault: %w", name, err)
+	}
+	return nil
+}
+
+// Problems returns the validation problems of the config, as ValidationErrors,
+// or nil when it is valid
+func (c *Config) Problems() error {
+	return c.problems
 }
 
 // GetAccount retrieves an account by name
@@ -180,6 +355,38 @@ func (c *Config) SetDefaultAccount(name string) error {
 	return fmt.Errorf("account '%s' not found", name)
 }
 
+// AddSignerFile adds an allowed_signers file whose entries are merged into the generated one
+func (c *Config) AddSignerFile(path string) error {
+	path = ExpandHome(path)
+	if slices.Contains(c.SignerFiles, path) {
+		return fmt.Errorf("%s is already imported", path)
+	}
+	c.SignerFiles = append(c.SignerFiles, path)
+	return c.save()
+}
+
+// RemoveSignerFile stops merging an allowed_signers file into the generated one
+func (c *Config) RemoveSignerFile(path string) error {
+	path = ExpandHome(path)
+	index := slices.Index(c.SignerFiles, path)
+	if index == -1 {
+		return fmt.Errorf("%s is not imported", path)
+	}
+	c.SignerFiles = slices.Delete(c.SignerFiles, index, index+1)
+	return c.save()
+}
+
+// AllowedSignersFile returns the path of the allowed_signers file ghmm maintains
+func (c *Config) AllowedSignersFile() string {
+	return filepath.Join(c.configDir, "allowed_signers")
+}
+
+// VaultFile returns the path of the encrypted token vault, kept apart from
+// config.yaml so tokens are never written in the clear
+func (c *Config) VaultFile() string {
+	return filepath.Join(c.configDir, "tokens.vault")
+}
+
 // GetDefaultAccount returns the default account name
 func (c *Config) GetDefaultAccount() string {
 	return c.DefaultAccount
@@ -189,3 +396,13 @@ func (c *Config) GetDefaultAccount() string {
 func (c *Config) ConfigFile() string {
 	return c.configFile
 }
+
+// ExpandHome expands a leading ~/ in path to the user's home directory
+func ExpandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
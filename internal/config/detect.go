@@ -1,186 +1,322 @@
 package config
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"sort"
 	"strings"
+
+	"github.com/donbowman/github-multi-account-manager/internal/gitconfig"
+	"github.com/donbowman/github-multi-account-manager/internal/ssh/sshconfig"
 )
 
-// DetectExistingSetup detects accounts from both .gitconfig and .ssh/config
-func DetectExistingSetup() ([]Account, error) {
+// Candidate is an account found by detection, along with how it was found
+// and how confidently it was paired with an SSH host alias
+type Candidate struct {
+	Account    Account
+	Source     string // Where the identity was found, e.g. "includeIf gitdir:~/work/ -> ~/.gitconfig-work"
+	Rule       string // Rule that paired the SSH alias, empty when unpaired
+	Confidence int    // 0-100 confidence in the SSH pairing, 0 when unpaired
+	Exists     bool   // An account with this name is already configured
+}
+
+// ReviewOnly reports whether the candidate can only be imported once reviewed.
+// gh's hosts.yml records no email, so users found only there need one entered.
+func (c Candidate) ReviewOnly() bool {
+	return c.Account.Email == ""
+}
+
+// DetectionReport lists everything detection found in the existing setup
+type DetectionReport struct {
+	Candidates []Candidate
+	// UnpairedAliases maps GitHub host aliases no candidate was paired with to their key
+	UnpairedAliases map[string]string
+}
+
+// minPairingConfidence is the lowest score at which an SSH alias is paired with a candidate
+const minPairingConfidence = 50
+
+// pairingRule scores how well an SSH alias and key fit a candidate account
+type pairingRule struct {
+	name  string
+	score int
+	match func(account Account, alias, keyPath string) bool
+}
+
+var pairingRules = []pairingRule{
+	{
+		name:  "alias suffix equals account name",
+		score: 95,
+		match: func(acc Account, alias, _ string) bool {
+			return strings.EqualFold(aliasSuffix(alias), acc.Name)
+		},
+	},
+	{
+		name:  "alias suffix equals GitHub username",
+		score: 90,
+		match: func(acc Account, alias, _ string) bool {
+			return strings.EqualFold(aliasSuffix(alias), acc.Username)
+		},
+	},
+	{
+		name:  "key file name contains account name",
+		score: 75,
+		match: func(acc Account, _, keyPath string) bool {
+			return hasToken(filepath.Base(keyPath), acc.Name)
+		},
+	},
+	{
+		name:  "key file name contains GitHub username",
+		score: 70,
+		match: func(acc Account, _, keyPath string) bool {
+			return hasToken(filepath.Base(keyPath), acc.Username)
+		},
+	},
+	{
+		name:  "alias suffix abbreviates account name",
+		score: 55,
+		match: func(acc Account, alias, _ string) bool {
+			suffix := strings.ToLower(aliasSuffix(alias))
+			return len(suffix) >= 2 && len(suffix) < len(acc.Name) &&
+				strings.HasPrefix(strings.ToLower(acc.Name), suffix)
+		},
+	},
+}
+
+// aliasSuffix returns the part of a host alias after "github.com-", or the whole alias
+func aliasSuffix(alias string) string {
+	return strings.TrimPrefix(alias, "github.com-")
+}
+
+// hasToken reports whether word appears in name as a whole token split on _ - and .
+func hasToken(name, word string) bool {
+	if word == "" {
+		return false
+	}
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+	for _, token := range tokens {
+		if token == strings.ToLower(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// scorePairing returns the best rule matching an account and alias
+func scorePairing(account Account, alias, keyPath string) (string, int) {
+	for _, rule := range pairingRules {
+		if rule.match(account, alias, keyPath) {
+			return rule.name, rule.score
+		}
+	}
+	return "", 0
+}
+
+// Detect inspects .gitconfig, .ssh/config, the repositories under scanRoots
+// and the gh CLI's hosts.yml and reports candidate accounts
+func Detect(scanRoots []string) (*DetectionReport, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	// First, get SSH config mappings (host alias -> SSH key path)
-	sshMap := parseSSHConfig(filepath.Join(home, ".ssh", "config"))
+	// First, get SSH config mappings (host alias -> SSH key path); a missing
+	// or unreadable ssh config leaves sshConfig nil
+	sshConfig, _ := sshconfig.Load(filepath.Join(home, ".ssh", "config"))
+	sshMap := parseSSHConfig(sshConfig)
 
 	// Then get git config mappings (directory -> email/name)
-	gitAccounts, err := parseGitconfigForAccounts(filepath.Join(home, ".gitconfig"), home)
+	candidates, err := parseGitconfigForAccounts(filepath.Join(home, ".gitconfig"), home)
 	if err != nil {
 		return nil, err
 	}
 
-	// Merge the information
-	for i := range gitAccounts {
-		// Try to find matching SSH config by looking for similar host alias or SSH key
-		for hostAlias, sshKey := range sshMap {
-			accountName := gitAccounts[i].Name
-			username := strings.ToLower(gitAccounts[i].Username)
-			hostSuffix := strings.TrimPrefix(hostAlias, "github.com-")
-			sshKeyLower := strings.ToLower(sshKey)
-
-			// Match strategies (in order of preference):
-			// 1. GitHub username is in the host alias (e.g., "thedonmon" in "github.com-thedonmon")
-			// 2. Host alias contains account name (e.g., "work" in "github.com-work")
-			// 3. SSH key path contains account name or username
-			// 4. Account name is abbreviated in host (e.g., "coldcart" -> "cc")
-			if strings.Contains(strings.ToLower(hostAlias), username) ||
-				strings.Contains(hostAlias, accountName) ||
-				strings.Contains(accountName, hostSuffix) ||
-				strings.Contains(sshKeyLower, accountName) ||
-				strings.Contains(sshKeyLower, username) ||
-				(len(accountName) > 2 && strings.HasPrefix(accountName, hostSuffix)) {
-				gitAccounts[i].HostAlias = hostAlias
-				gitAccounts[i].SSHKeyPath = sshKey
-				break
+	// Next, group repositories by the alias and email they use
+	candidates = mergeScanned(candidates, scanRepositories(scanRoots, sshConfig, sshMap))
+
+	// Finally, add the users the gh CLI is logged in as
+	ghPath, err := GHHostsPath()
+	if err != nil {
+		return nil, err
+	}
+	ghCandidates, err := ParseGHHosts(ghPath)
+	if err != nil {
+		return nil, err
+	}
+	candidates = mergeGHHosts(candidates, ghCandidates)
+
+	return &DetectionReport{
+		Candidates:      candidates,
+		UnpairedAliases: pairCandidates(candidates, sshMap),
+	}, nil
+}
+
+// pairCandidates assigns each SSH alias to at most one candidate, best scores
+// first, and returns the aliases left over
+func pairCandidates(candidates []Candidate, sshMap map[string]string) map[string]string {
+	type pairing struct {
+		candidate int
+		alias     string
+		rule      string
+		score     int
+	}
+
+	var pairings []pairing
+	for i, candidate := range candidates {
+		for alias, keyPath := range sshMap {
+			if rule, score := scorePairing(candidate.Account, alias, keyPath); score >= minPairingConfidence {
+				pairings = append(pairings, pairing{i, alias, rule, score})
 			}
 		}
 	}
 
-	return gitAccounts, nil
-}
+	sort.Slice(pairings, func(i, j int) bool {
+		if pairings[i].score != pairings[j].score {
+			return pairings[i].score > pairings[j].score
+		}
+		// Keep the result stable when scores tie
+		if pairings[i].candidate != pairings[j].candidate {
+			return pairings[i].candidate < pairings[j].candidate
+		}
+		return pairings[i].alias < pairings[j].alias
+	})
 
-// parseSSHConfig extracts GitHub host aliases and their SSH keys from ~/.ssh/config
-func parseSSHConfig(configPath string) map[string]string {
-	result := make(map[string]string)
+	unpaired := make(map[string]string, len(sshMap))
+	for alias, keyPath := range sshMap {
+		unpaired[alias] = keyPath
+	}
+	// Candidates found through their repositories' remotes already own their alias
+	for _, candidate := range candidates {
+		if candidate.Rule != "" {
+			delete(unpaired, candidate.Account.HostAlias)
+		}
+	}
+
+	for _, p := range pairings {
+		candidate := &candidates[p.candidate]
+		if candidate.Rule != "" {
+			continue
+		}
+		if _, free := unpaired[p.alias]; !free {
+			continue
+		}
+		candidate.Account.HostAlias = p.alias
+		candidate.Account.SSHKeyPath = sshMap[p.alias]
+		candidate.Rule = p.rule
+		candidate.Confidence = p.score
+		delete(unpaired, p.alias)
+	}
 
-	data, err := os.ReadFile(configPath)
+	return unpaired
+}
+
+// DetectionReport runs detection and marks candidates that are already configured
+func (c *Config) DetectionReport() (*DetectionReport, error) {
+	report, err := Detect(c.scanRoots())
 	if err != nil {
-		return result
+		return nil, err
 	}
 
-	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-	var currentHost string
+	for i := range report.Candidates {
+		_, err := c.GetAccount(report.Candidates[i].Account.Name)
+		report.Candidates[i].Exists = err == nil
+	}
+	return report, nil
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// parseSSHConfig extracts GitHub host aliases and their SSH keys from ~/.ssh/config.
+// Each alias is resolved the way ssh would, so Include, Match and Host * defaults apply.
+func parseSSHConfig(cfg *sshconfig.Config) map[string]string {
+	result := make(map[string]string)
+	if cfg == nil {
+		return result
+	}
 
-		// Match: Host github.com-something
-		if strings.HasPrefix(line, "Host ") {
-			host := strings.TrimPrefix(line, "Host ")
-			host = strings.TrimSpace(host)
-			if strings.HasPrefix(host, "github.com-") {
-				currentHost = host
-			}
+	for _, alias := range cfg.Aliases() {
+		if alias == "github.com" {
+			continue
 		}
-
-		// Match: IdentityFile ~/.ssh/something_ssh
-		if currentHost != "" && strings.Contains(line, "IdentityFile") {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				identityFile := parts[1]
-				// Expand ~
-				if strings.HasPrefix(identityFile, "~/") {
-					home, _ := os.UserHomeDir()
-					identityFile = filepath.Join(home, identityFile[2:])
-				}
-				result[currentHost] = identityFile
-				currentHost = ""
-			}
+		resolved := cfg.Resolve(alias)
+		if resolved.HostName == "github.com" && len(resolved.IdentityFiles) > 0 {
+			result[alias] = resolved.IdentityFiles[0]
 		}
 	}
 
 	return result
 }
 
-// parseGitconfigForAccounts extracts account info from gitconfig includeIf directives
-func parseGitconfigForAccounts(configPath, home string) ([]Account, error) {
-	var accounts []Account
+// parseGitconfigForAccounts extracts account info from gitconfig includeIf directives.
+// Several includeIfs pointing at the same file become one account with several directories.
+func parseGitconfigForAccounts(configPath, home string) ([]Candidate, error) {
+	var candidates []Candidate
+	byFile := map[string]int{}
 
-	data, err := os.ReadFile(configPath)
+	includes, err := gitconfig.ConditionalIncludes(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Repositories may still be found by scanning
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read .gitconfig: %w", err)
 	}
 
-	// Regex to match: [includeIf "gitdir:/path/to/dir/**"]
-	includeIfRe := regexp.MustCompile(`\[includeIf\s+"gitdir:([^"]+)"\]`)
-	pathRe := regexp.MustCompile(`path\s*=\s*(.+)`)
-
-	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-
-	var currentDir string
+	for _, include := range includes {
+		kind, pattern, _ := strings.Cut(include.Condition, ":")
+		if kind != "gitdir" && kind != "gitdir/i" {
+			continue
+		}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		directory := gitdirToDirectory(pattern, home)
+		source := fmt.Sprintf("includeIf %s -> %s", include.Condition, include.Path)
 
-		// Check for includeIf directive
-		if matches := includeIfRe.FindStringSubmatch(line); matches != nil {
-			gitdir := matches[1]
-			// Remove trailing /** or **
-			gitdir = strings.TrimSuffix(gitdir, "/**")
-			gitdir = strings.TrimSuffix(gitdir, "**")
-			gitdir = strings.TrimSuffix(gitdir, "/")
-			// Expand ~
-			if strings.HasPrefix(gitdir, "~/") {
-				gitdir = filepath.Join(home, gitdir[2:])
+		if i, ok := byFile[include.ResolvedPath()]; ok {
+			candidate := &candidates[i]
+			if !slices.Contains(candidate.Account.Directories, directory) {
+				candidate.Account.Directories = append(candidate.Account.Directories, directory)
 			}
-			currentDir = gitdir
+			candidate.Source += ", " + source
+			continue
 		}
 
-		// Check for path directive
-		if currentDir != "" && strings.Contains(line, "path") {
-			if matches := pathRe.FindStringSubmatch(line); matches != nil {
-				currentPath := strings.TrimSpace(matches[1])
-				// Expand ~
-				if strings.HasPrefix(currentPath, "~/") {
-					currentPath = filepath.Join(home, currentPath[2:])
-				}
-
-				// Try to parse the included config file
-				if account, err := parseIncludedGitconfig(currentPath, currentDir, home); err == nil {
-					accounts = append(accounts, account)
-				}
-
-				currentDir = ""
-			}
+		// Try to parse the included config file
+		if account, err := parseIncludedGitconfig(include.ResolvedPath(), directory, home); err == nil {
+			byFile[include.ResolvedPath()] = len(candidates)
+			candidates = append(candidates, Candidate{Account: account, Source: source})
 		}
 	}
 
-	return accounts, nil
+	return candidates, nil
+}
+
+// gitdirToDirectory turns a gitdir: pattern like ~/code/work/** into a plain directory
+func gitdirToDirectory(pattern, home string) string {
+	// Remove trailing /** or **
+	gitdir := strings.TrimSuffix(pattern, "/**")
+	gitdir = strings.TrimSuffix(gitdir, "**")
+	gitdir = strings.TrimSuffix(gitdir, "/")
+	// Expand ~
+	if strings.HasPrefix(gitdir, "~/") {
+		gitdir = filepath.Join(home, gitdir[2:])
+	}
+	return gitdir
 }
 
 // parseIncludedGitconfig reads a gitconfig-* file and extracts user info
 func parseIncludedGitconfig(configPath, directory, home string) (Account, error) {
-	data, err := os.ReadFile(configPath)
+	// Evaluate the file as git would for a repository inside the directory,
+	// so nested includes and includeIfs are honoured
+	cfg, err := gitconfig.Load(configPath, gitconfig.Context{GitDir: filepath.Join(directory, ".git")})
 	if err != nil {
 		return Account{}, err
 	}
 
-	var name, email string
-	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		if strings.Contains(line, "name") && strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				name = strings.TrimSpace(parts[1])
-			}
-		}
-
-		if strings.Contains(line, "email") && strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				email = strings.TrimSpace(parts[1])
-			}
-		}
-	}
+	name, _ := cfg.Get("user.name")
+	email, _ := cfg.Get("user.email")
 
 	if name == "" || email == "" {
 		return Account{}, fmt.Errorf("incomplete account info in %s", configPath)
@@ -189,26 +325,17 @@ func parseIncludedGitconfig(configPath, directory, home string) (Account, error)
 	// Extract account name from config filename or directory
 	accountName := extractAccountName(configPath, home)
 
-	// Try to extract GitHub username from email if it's a noreply address
-	// Format: 12345+username@users.noreply.github.com
-	githubUsername := name
-	if strings.Contains(email, "@users.noreply.github.com") {
-		parts := strings.Split(email, "@")
-		if len(parts) > 0 {
-			userPart := parts[0]
-			if plusIdx := strings.Index(userPart, "+"); plusIdx != -1 {
-				githubUsername = userPart[plusIdx+1:]
-			}
-		}
-	}
+	// Only a noreply address gives the GitHub username away; user.name is a
+	// display name. Otherwise it comes from remotes or gh, or is asked for
+	githubUsername := usernameFromEmail(email, "")
 
 	return Account{
-		Name:       accountName,
-		Username:   githubUsername,
-		Email:      email,
-		Directory:  directory,
-		SSHKeyPath: "", // Will be filled from SSH config
-		HostAlias:  "", // Will be filled from SSH config
+		Name:        accountName,
+		Username:    githubUsername,
+		Email:       email,
+		Directories: []string{directory},
+		SSHKeyPath:  "", // Will be filled from SSH config
+		HostAlias:   "", // Will be filled from SSH config
 	}, nil
 }
 
@@ -232,15 +359,13 @@ func extractAccountName(configPath, home string) string {
 	return baseName
 }
 
-// ImportFromExistingSetup imports detected accounts into the config
-func (c *Config) ImportFromExistingSetup() (int, error) {
-	detected, err := DetectExistingSetup()
-	if err != nil {
-		return 0, err
-	}
-
+// ImportAccounts adds accounts that aren't configured yet, filling in a
+// default SSH key path and host alias where detection found none. Accounts
+// that fail validation are skipped and reported in the returned ValidationErrors.
+func (c *Config) ImportAccounts(accounts []Account) (int, error) {
 	imported := 0
-	for _, account := range detected {
+	var skipped ValidationErrors
+	for _, account := range accounts {
 		// Check if already exists
 		exists := false
 		for _, existing := range c.Accounts {
@@ -258,10 +383,16 @@ func (c *Config) ImportFromExistingSetup() (int, error) {
 				account.SSHKeyPath = filepath.Join(home, ".ssh", account.Name+"_ssh")
 			}
 			if account.HostAlias == "" {
-				account.HostAlias = "github.com-" + account.Name
+				account.HostAlias = account.GitHubHost() + "-" + account.Name
 			}
 
-			c.Accounts = append(c.Accounts, account)
+			candidate := append(slices.Clone(c.Accounts), account)
+			if err := validateChange(candidate, "", account.Name); err != nil {
+				skipped = append(skipped, err.(ValidationErrors)...)
+				continue
+			}
+
+			c.Accounts = candidate
 			imported++
 		}
 	}
@@ -277,5 +408,8 @@ func (c *Config) ImportFromExistingSetup() (int, error) {
 		}
 	}
 
+	if len(skipped) > 0 {
+		return imported, skipped
+	}
 	return imported, nil
 }
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ghHost is one host entry in the gh CLI's hosts.yml
+type ghHost struct {
+	User        string            `yaml:"user"` // Active user
+	GitProtocol string            `yaml:"git_protocol"`
+	Users       map[string]ghUser `yaml:"users"` // Every user logged in to the host
+}
+
+// ghUser holds per-user settings in hosts.yml; only the key, the username, is used
+type ghUser struct{}
+
+// GHHostsPath returns the location of the gh CLI's hosts.yml, honouring
+// GH_CONFIG_DIR and XDG_CONFIG_HOME the way gh does
+func GHHostsPath() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml"), nil
+}
+
+// ParseGHHosts lists every host/user pair in a gh hosts.yml as a candidate account.
+// A missing file yields no candidates.
+func ParseGHHosts(path string) ([]Candidate, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gh hosts file: %w", err)
+	}
+
+	var hosts map[string]ghHost
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return nil, fmt.Errorf("failed to parse gh hosts file: %w", err)
+	}
+
+	home, _ := os.UserHomeDir()
+
+	hostNames := make([]string, 0, len(hosts))
+	for host := range hosts {
+		hostNames = append(hostNames, host)
+	}
+	sort.Strings(hostNames)
+
+	var candidates []Candidate
+	for _, host := range hostNames {
+		entry := hosts[host]
+
+		// Older gh versions only record the single active user
+		users := make([]string, 0, len(entry.Users)+1)
+		for user := range entry.Users {
+			users = append(users, user)
+		}
+		if entry.User != "" && !slices.Contains(users, entry.User) {
+			users = append(users, entry.User)
+		}
+		sort.Strings(users)
+
+		for _, user := range users {
+			name := user
+			account := Account{Username: user, GitProtocol: entry.GitProtocol}
+			if host != DefaultHost {
+				// Keep names unique when the same user exists on GitHub Enterprise
+				name = user + "-" + strings.Split(host, ".")[0]
+				account.Host = host
+			}
+			account.Name = name
+			account.Directories = []string{filepath.Join(home, "code", name)}
+
+			source := fmt.Sprintf("gh hosts.yml (%s", host)
+			if user == entry.User {
+				source += ", active user"
+			}
+			candidates = append(candidates, Candidate{Account: account, Source: source + ")"})
+		}
+	}
+
+	return candidates, nil
+}
+
+// mergeGHHosts fills in the host and git protocol of candidates gh also knows
+// about and adds the gh users that weren't found anywhere else
+func mergeGHHosts(candidates, ghCandidates []Candidate) []Candidate {
+	for _, gh := range ghCandidates {
+		merged := false
+		for i := range candidates {
+			acc := &candidates[i].Account
+			if !strings.EqualFold(acc.Username, gh.Account.Username) || acc.GitHubHost() != gh.Account.GitHubHost() {
+				continue
+			}
+			acc.Host = gh.Account.Host
+			acc.GitProtocol = gh.Account.GitProtocol
+			candidates[i].Source += ", " + gh.Source
+			merged = true
+			break
+		}
+		if !merged {
+			gh.Account.Name = uniqueName(candidates, gh.Account.Name)
+			candidates = append(candidates, gh)
+		}
+	}
+	return candidates
+}
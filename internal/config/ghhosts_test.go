@@ -0,0 +1,119 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGHHosts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	candidates, err := ParseGHHosts(filepath.Join("testdata", "hosts.yml"))
+	if err != nil {
+		t.Fatalf("ParseGHHosts() error = %v", err)
+	}
+
+	// Hosts and then users are sorted; enterprise users are named after their host
+	want := []struct {
+		name, username, host, protocol string
+		active                         bool
+	}{
+		{"bob-ghe", "bob", "ghe.example.org", "ssh", true},
+		{"alice-github", "alice", "github.acme-corp.com", "https", true},
+		{"alice", "alice", "", "ssh", false},
+		{"alice-work", "alice-work", "", "ssh", true},
+	}
+	if len(candidates) != len(want) {
+		t.Fatalf("got %d candidates, want %d: %+v", len(candidates), len(want), candidates)
+	}
+
+	for i, w := range want {
+		acc := candidates[i].Account
+		if acc.Name != w.name || acc.Username != w.username || acc.Host != w.host || acc.GitProtocol != w.protocol {
+			t.Errorf("candidate %d = {name: %s, username: %s, host: %s, protocol: %s}, want %+v",
+				i, acc.Name, acc.Username, acc.Host, acc.GitProtocol, w)
+		}
+		if dir := filepath.Join(home, "code", w.name); acc.PrimaryDirectory() != dir {
+			t.Errorf("candidate %d directory = %s, want %s", i, acc.PrimaryDirectory(), dir)
+		}
+		if got := strings.Contains(candidates[i].Source, "active user"); got != w.active {
+			t.Errorf("candidate %d source = %q, active user = %v, want %v", i, candidates[i].Source, got, w.active)
+		}
+	}
+}
+
+func TestParseGHHostsMissingFile(t *testing.T) {
+	candidates, err := ParseGHHosts(filepath.Join(t.TempDir(), "hosts.yml"))
+	if err != nil || candidates != nil {
+		t.Errorf("ParseGHHosts() = %v, %v, want no candidates and no error", candidates, err)
+	}
+}
+
+func TestMergeGHHosts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ghCandidates, err := ParseGHHosts(filepath.Join("testdata", "hosts.yml"))
+	if err != nil {
+		t.Fatalf("ParseGHHosts() error = %v", err)
+	}
+
+	candidates := []Candidate{
+		{Account: Account{Name: "alice", Username: "alice", Email: "alice@example.com"}, Source: "~/.gitconfig"},
+		{Account: Account{Name: "acme", Username: "alice", Host: "github.acme-corp.com"}, Source: "~/.ssh/config"},
+	}
+	merged := mergeGHHosts(candidates, ghCandidates)
+
+	// Both existing candidates pick up gh's settings; the other two users are added
+	if len(merged) != 4 {
+		t.Fatalf("got %d candidates, want 4: %+v", len(merged), merged)
+	}
+	if acc := merged[0].Account; acc.GitProtocol != "ssh" || acc.Host != "" || acc.Email != "alice@example.com" {
+		t.Errorf("github.com candidate = %+v, want ssh on the default host with its email kept", acc)
+	}
+	if acc := merged[1].Account; acc.GitProtocol != "https" || acc.Host != "github.acme-corp.com" {
+		t.Errorf("enterprise candidate = %+v, want https on github.acme-corp.com", acc)
+	}
+	for _, c := range merged[2:] {
+		if c.Account.Username == "alice" {
+			t.Errorf("alice was added again from %s", c.Source)
+		}
+	}
+}
+
+func TestImportSkipsGHOnlyCandidates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ghCandidates, err := ParseGHHosts(filepath.Join("testdata", "hosts.yml"))
+	if err != nil {
+		t.Fatalf("ParseGHHosts() error = %v", err)
+	}
+	candidates := mergeGHHosts([]Candidate{
+		{Account: Account{Name: "alice", Username: "alice", Email: "alice@example.com", Directories: []string{"/code/alice"}}},
+	}, ghCandidates)
+
+	// A plain import takes what it can; gh-only users have no email and wait for review
+	var accounts []Account
+	for _, c := range candidates {
+		if !c.ReviewOnly() {
+			accounts = append(accounts, c.Account)
+		}
+	}
+	if len(candidates) != 4 || len(accounts) != 1 || accounts[0].Name != "alice" {
+		t.Fatalf("importable = %+v of %d candidates, want only alice of 4", accounts, len(candidates))
+	}
+
+	cfg, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := cfg.ImportAccounts(accounts)
+	if err != nil || imported != 1 {
+		t.Errorf("ImportAccounts() = %d, %v, want 1 and no error", imported, err)
+	}
+
+	// Importing a gh-only user as is fails validation on the missing email
+	ghOnly := candidates[len(candidates)-1].Account
+	if _, err := cfg.ImportAccounts([]Account{ghOnly}); err == nil {
+		t.Errorf("ImportAccounts(%s) error = nil, want the missing email reported", ghOnly.Name)
+	}
+}
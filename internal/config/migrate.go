@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config schema version this build reads and writes.
+// Files written before versioning was added have no version key and are treated as version 1.
+const CurrentVersion = 3
+
+// migration upgrades a raw config document from one version to the next
+type migration struct {
+	from        int
+	description string
+	apply       func(doc map[string]any) error
+}
+
+// migrations must be listed in order, one step per version
+var migrations = []migration{
+	{
+		from:        1,
+		description: "expand ~ in directories and SSH key paths and fill in missing host aliases",
+		apply:       migrateV1ToV2,
+	},
+	{
+		from:        2,
+		description: "replace each account's directory with a list of directories",
+		apply:       migrateV2ToV3,
+	},
+}
+
+// MigrationStatus describes the config file's version and the migrations it still needs
+type MigrationStatus struct {
+	File    string
+	Version int
+	Pending []string // Descriptions of the migrations that would run, in order
+}
+
+// CheckMigrations reports which migrations the config file in configDir needs,
+// without changing it. An empty configDir means ~/.ghmm.
+func CheckMigrations(configDir string) (*MigrationStatus, error) {
+	configDir, err := resolveConfigDir(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{File: filepath.Join(configDir, "config.yaml"), Version: CurrentVersion}
+
+	data, err := os.ReadFile(status.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	_, version, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	status.Version = version
+
+	for _, m := range migrations {
+		if m.from >= version {
+			status.Pending = append(status.Pending, fmt.Sprintf("v%d → v%d: %s", m.from, m.from+1, m.description))
+		}
+	}
+	return status, nil
+}
+
+// parseDocument decodes a config file into a generic document and returns its schema version
+func parseDocument(data []byte) (map[string]any, int, error) {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	version := 1
+	if raw, ok := doc["version"]; ok {
+		v, ok := raw.(int)
+		if !ok || v < 1 {
+			return nil, 0, fmt.Errorf("invalid config version %v", raw)
+		}
+		version = v
+	}
+
+	if version > CurrentVersion {
+		return nil, 0, fmt.Errorf("config file version %d is newer than this ghmm supports (%d); please upgrade ghmm", version, CurrentVersion)
+	}
+	return doc, version, nil
+}
+
+// migrate upgrades config file contents older than CurrentVersion, step by step,
+// and keeps the original for backUp. It returns the upgraded contents.
+func (c *Config) migrate(data []byte) ([]byte, error) {
+	doc, version, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if version == CurrentVersion {
+		return data, nil
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		if err := m.apply(doc); err != nil {
+			return nil, fmt.Errorf("failed to migrate config from v%d: %w", m.from, err)
+		}
+		c.migrated = append(c.migrated, m.description)
+	}
+	doc["version"] = CurrentVersion
+	c.original = data
+	c.fromVersion = version
+
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+	return migrated, nil
+}
+
+// backUp saves the pre-migration file next to the config, just before the
+// migrated config replaces it. An identical earlier backup is reused and a
+// different one is never overwritten.
+func (c *Config) backUp() error {
+	backup := fmt.Sprintf("%s.v%d.bak", c.configFile, c.fromVersion)
+	if existing, err := os.ReadFile(backup); err == nil {
+		if bytes.Equal(existing, c.original) {
+			c.backupFile = backup
+			return nil
+		}
+		backup = fmt.Sprintf("%s.%d", backup, time.Now().Unix())
+	}
+	if err := os.WriteFile(backup, c.original, 0644); err != nil {
+		return fmt.Errorf("failed to back up config file: %w", err)
+	}
+	c.backupFile = backup
+	return nil
+}
+
+// Migrated returns the migrations applied when the config was loaded
+func (c *Config) Migrated() []string {
+	return c.migrated
+}
+
+// BackupFile returns where the pre-migration config was saved, if a migration ran
+func (c *Config) BackupFile() string {
+	return c.backupFile
+}
+
+// migrateV1ToV2 normalizes accounts written by versions that stored ~ paths
+// and left the host alias empty
+func migrateV1ToV2(doc map[string]any) error {
+	return eachAccount(doc, func(account map[string]any) error {
+		for _, key := range []string{"directory", "ssh_key_path"} {
+			if path, ok := account[key].(string); ok {
+				account[key] = ExpandHome(path)
+			}
+		}
+
+		name, _ := account["name"].(string)
+		if alias, _ := account["host_alias"].(string); strings.TrimSpace(alias) == "" && name != "" {
+			account["host_alias"] = DefaultHost + "-" + name
+		}
+		return nil
+	})
+}
+
+// migrateV2ToV3 moves the single directory of each account into a list
+func migrateV2ToV3(doc map[string]any) error {
+	return eachAccount(doc, func(account map[string]any) error {
+		if _, ok := account["directories"]; ok {
+			delete(account, "directory")
+			return nil
+		}
+		switch directory := account["directory"].(type) {
+		case nil:
+			account["directories"] = []any{}
+		case string:
+			account["directories"] = []any{directory}
+		case []any:
+			// Hand-edited files sometimes already list several
+			account["directories"] = directory
+		default:
+			return fmt.Errorf("directory of account %v is not a string", account["name"])
+		}
+		delete(account, "directory")
+		return nil
+	})
+}
+
+// eachAccount calls fn with every account mapping in the document
+func eachAccount(doc map[string]any, fn func(account map[string]any) error) error {
+	raw, ok := doc["accounts"]
+	if !ok || raw == nil {
+		return nil
+	}
+	accounts, ok := raw.([]any)
+	if !ok {
+		return fmt.Errorf("accounts is not a list")
+	}
+
+	for i, item := range accounts {
+		account, ok := item.(map[string]any)
+		if !ok {
+			return fmt.Errorf("account %d is not a mapping", i+1)
+		}
+		if err := fn(account); err != nil {
+			return err
+		}
+	}
+	return nil
+}
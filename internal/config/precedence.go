@@ -0,0 +1,128 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Mapping ties one of an account's directories to the account
+type Mapping struct {
+	Account   Account
+	Directory string
+}
+
+// Overlap is a pair of mappings where one directory is nested inside the other.
+// Inner takes precedence for everything under its directory.
+type Overlap struct {
+	Outer Mapping
+	Inner Mapping
+}
+
+// Resolution explains which account owns a path
+type Resolution struct {
+	Path    string
+	Account *Account  // Winning account, nil when nothing matches and there is no default
+	Matches []Mapping // Every mapping whose directory contains Path, most specific first
+	// Default is set when nothing matched and Account is the default account.
+	// Only gclone falls back to it; git uses the global user.name/user.email.
+	Default bool
+	// RemoteURL and RemotePattern are set when Account was chosen by the
+	// repository's remote URL, which takes precedence over directories
+	RemoteURL     string
+	RemotePattern string
+}
+
+// DirectoryMappings returns every account directory ordered from least to
+// most specific. This is the order includeIf blocks must appear in, since git
+// applies later includes last and so the most specific directory wins.
+func DirectoryMappings(accounts []Account) []Mapping {
+	var mappings []Mapping
+	for _, account := range accounts {
+		for _, dir := range account.Directories {
+			mappings = append(mappings, Mapping{Account: account, Directory: dir})
+		}
+	}
+	slices.SortStableFunc(mappings, func(a, b Mapping) int {
+		return directoryDepth(a.Directory) - directoryDepth(b.Directory)
+	})
+	return mappings
+}
+
+// directoryDepth counts the path elements in an account directory
+func directoryDepth(directory string) int {
+	dir := filepath.Clean(ExpandHome(directory))
+	return len(strings.Split(strings.Trim(dir, string(filepath.Separator)), string(filepath.Separator)))
+}
+
+// Overlaps lists every pair of directories belonging to different accounts where one is nested in the other
+func (c *Config) Overlaps() []Overlap {
+	mappings := DirectoryMappings(c.Accounts)
+
+	var overlaps []Overlap
+	for _, outer := range mappings {
+		for _, inner := range mappings {
+			if outer.Account.Name != inner.Account.Name &&
+				isWithin(filepath.Clean(ExpandHome(inner.Directory)), filepath.Clean(ExpandHome(outer.Directory))) {
+				overlaps = append(overlaps, Overlap{Outer: outer, Inner: inner})
+			}
+		}
+	}
+	return overlaps
+}
+
+// Resolve works out which account owns path the way the generated gitconfig
+// does: an account whose remote patterns match the repository's remotes, then
+// the account with the longest directory containing path. When neither applies
+// it returns the default account with Default set, which is what gclone uses;
+// the gitconfig has no rule for such paths, so git uses the global identity.
+func (c *Config) Resolve(path string) Resolution {
+	path = ExpandHome(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	// Compare real paths so symlinked checkouts resolve the way git sees them
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+
+	resolution := Resolution{Path: path}
+	mappings := DirectoryMappings(c.Accounts)
+	for i := len(mappings) - 1; i >= 0; i-- {
+		dir := ExpandHome(mappings[i].Directory)
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = real
+		}
+		if path == filepath.Clean(dir) || isWithin(path, filepath.Clean(dir)) {
+			resolution.Matches = append(resolution.Matches, mappings[i])
+		}
+	}
+
+	// Remote URL rules are written after directory rules and the last match
+	// wins, so check every account and keep the last one that matches
+	remotes := repositoryRemotes(path)
+	for i := range c.Accounts {
+		for _, url := range remotes {
+			if glob := c.Accounts[i].MatchRemote(url); glob != "" {
+				resolution.Account = &c.Accounts[i]
+				resolution.RemoteURL = url
+				resolution.RemotePattern = glob
+				break
+			}
+		}
+	}
+	if resolution.RemoteURL != "" {
+		return resolution
+	}
+
+	if len(resolution.Matches) > 0 {
+		resolution.Account = &resolution.Matches[0].Account
+		return resolution
+	}
+
+	if account, err := c.GetAccount(c.DefaultAccount); err == nil {
+		resolution.Account = account
+		resolution.Default = true
+	}
+	return resolution
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donbowman/github-multi-account-manager/internal/gitconfig"
+)
+
+// isURLGlob reports whether a remote pattern is already written as a git URL
+// glob, e.g. git@github.com:acme-corp/**, rather than host/owner/**
+func isURLGlob(pattern string) bool {
+	return strings.Contains(pattern, "://") || strings.Contains(pattern, "@")
+}
+
+// validateRemotePattern returns why a remote pattern is invalid, or ""
+func validateRemotePattern(pattern string) string {
+	if pattern == "" {
+		return "cannot be empty"
+	}
+	if strings.ContainsAny(pattern, " \t\"") {
+		return "cannot contain whitespace or quotes"
+	}
+	if isURLGlob(pattern) {
+		return ""
+	}
+	host, rest, ok := strings.Cut(pattern, "/")
+	if !ok || rest == "" || !hostRe.MatchString(host) {
+		return "must look like host/owner/** or be a full remote URL pattern"
+	}
+	return ""
+}
+
+// RemoteURLPatterns expands the account's remote patterns into the URL globs
+// matched by git's hasconfig:remote.*.url: condition. A host/owner/** pattern
+// covers the HTTPS, SSH and scp-style URLs for the host, plus the account's
+// host alias when the host is the account's own.
+func (a Account) RemoteURLPatterns() []string {
+	var globs []string
+	for _, pattern := range a.RemotePatterns {
+		if isURLGlob(pattern) {
+			globs = append(globs, pattern)
+			continue
+		}
+
+		host, rest, _ := strings.Cut(pattern, "/")
+		globs = append(globs,
+			"https://"+host+"/"+rest,
+			"ssh://git@"+host+"/"+rest,
+			"git@"+host+":"+rest,
+		)
+		if host == a.GitHubHost() && a.HostAlias != "" {
+			globs = append(globs, "git@"+a.HostAlias+":"+rest)
+		}
+	}
+	return globs
+}
+
+// MatchRemote returns the first of the account's URL globs that url matches, or ""
+func (a Account) MatchRemote(url string) string {
+	for _, glob := range a.RemoteURLPatterns() {
+		if gitconfig.MatchURL(glob, url) {
+			return glob
+		}
+	}
+	return ""
+}
+
+// repositoryRemotes finds the repository containing path and returns its remote URLs
+func repositoryRemotes(path string) []string {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			file, err := gitconfig.ParseFile(filepath.Join(repoGitDir(dir), "config"))
+			if err != nil {
+				return nil
+			}
+			var urls []string
+			for _, e := range file.Entries {
+				if e.Section == "remote" && e.Key == "url" {
+					urls = append(urls, e.Value)
+				}
+			}
+			return urls
+		}
+		if filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+}
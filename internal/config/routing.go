@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Route is the account picked for a repository and why
+type Route struct {
+	Account *Account // nil when nothing claims the owner and no directory or default applies
+	Reason  string
+	// Claimants lists every account whose orgs include the owner
+	Claimants []Account
+}
+
+// OrgOwners maps each lowercased org to the accounts that list it, in config order
+func OrgOwners(accounts []Account) map[string][]Account {
+	owners := map[string][]Account{}
+	for _, account := range accounts {
+		for _, org := range account.Orgs {
+			org = strings.ToLower(org)
+			owners[org] = append(owners[org], account)
+		}
+	}
+	return owners
+}
+
+// SortedOrgs returns the keys of an OrgOwners map in order
+func SortedOrgs(owners map[string][]Account) []string {
+	orgs := make([]string, 0, len(owners))
+	for org := range owners {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	return orgs
+}
+
+// RouteRepository picks the account for a repository owned by owner that lives,
+// or will be cloned, at path. The one account listing the owner in its orgs
+// wins; otherwise the account is chosen by directory, then the default.
+func (c *Config) RouteRepository(owner, path string) Route {
+	claimants := OrgOwners(c.Accounts)[strings.ToLower(owner)]
+	route := Route{Claimants: claimants}
+
+	if len(claimants) == 1 {
+		account := claimants[0]
+		route.Account = &account
+		route.Reason = fmt.Sprintf("%s is listed in the orgs of '%s'", owner, account.Name)
+		return route
+	}
+
+	resolution := c.Resolve(path)
+	route.Account = resolution.Account
+
+	if len(claimants) > 1 {
+		names := make([]string, len(claimants))
+		for i, claimant := range claimants {
+			names[i] = claimant.Name
+			if resolution.Account != nil && !resolution.Default && claimant.Name == resolution.Account.Name {
+				route.Reason = fmt.Sprintf("%s is listed by several accounts; '%s' owns %s", owner, claimant.Name, resolution.Path)
+				return route
+			}
+		}
+		// The directory doesn't settle it, so fall back to the first account listing the owner
+		route.Account = &claimants[0]
+		route.Reason = fmt.Sprintf("%s is listed by several accounts (%s); using '%s'. Remove it from all but one account's orgs",
+			owner, strings.Join(names, ", "), claimants[0].Name)
+		return route
+	}
+
+	switch {
+	case resolution.Account == nil:
+		route.Reason = fmt.Sprintf("no account lists %s in its orgs and no directory or default account applies", owner)
+	case resolution.Default:
+		route.Reason = fmt.Sprintf("no account lists %s in its orgs; using the default account", owner)
+	default:
+		route.Reason = fmt.Sprintf("no account lists %s in its orgs; '%s' owns %s", owner, resolution.Account.Name, resolution.Path)
+	}
+	return route
+}
+
+// URLRewrite sends URLs for an org's repositories on the account's host
+// through the account's host alias
+type URLRewrite struct {
+	Account   Account
+	Org       string
+	Base      string   // e.g. git@github.com-work:acme-corp/
+	InsteadOf []string // URL prefixes git replaces with Base
+}
+
+// Matches reports whether git would rewrite url with this rule
+func (r URLRewrite) Matches(url string) bool {
+	for _, prefix := range r.InsteadOf {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// URLRewrites returns the insteadOf rules for accounts with rewrite_urls set.
+// A rewrite applies everywhere, so orgs listed by several accounts can't be
+// rewritten and are returned as ambiguous instead.
+func URLRewrites(accounts []Account) (rewrites []URLRewrite, ambiguous []string) {
+	owners := OrgOwners(accounts)
+	for _, account := range accounts {
+		// Accounts using core.sshCommand have no ssh config block to rewrite to
+		if !account.RewriteURLs || account.HostAlias == "" || account.UsesSSHCommand() {
+			continue
+		}
+		host := account.GitHubHost()
+		for _, org := range account.Orgs {
+			if len(owners[strings.ToLower(org)]) > 1 {
+				if !slices.Contains(ambiguous, org) {
+					ambiguous = append(ambiguous, org)
+				}
+				continue
+			}
+			rewrites = append(rewrites, URLRewrite{
+				Account: account,
+				Org:     org,
+				Base:    "git@" + account.HostAlias + ":" + org + "/",
+				InsteadOf: []string{
+					"git@" + host + ":" + org + "/",
+					"ssh://git@" + host + "/" + org + "/",
+					"https://" + host + "/" + org + "/",
+				},
+			})
+		}
+	}
+	return rewrites, ambiguous
+}
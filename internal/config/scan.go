@@ -0,0 +1,366 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/donbowman/github-multi-account-manager/internal/gitconfig"
+	"github.com/donbowman/github-multi-account-manager/internal/ssh/sshconfig"
+	"github.com/donbowman/github-multi-account-manager/internal/workspace"
+)
+
+// DefaultScanRoots are searched for repositories when scan_roots isn't set in the config
+var DefaultScanRoots = []string{"~/code", "~/src", "~/SourceCode"}
+
+// recentCommits is how many commits per repository are checked for author emails
+const recentCommits = 20
+
+// repoIdentity is what a single repository says about who works in it
+type repoIdentity struct {
+	path    string
+	alias   string   // Host alias used by a remote, empty for plain github.com
+	owners  []string // Owners of the repository's remotes
+	email   string   // Repository-local user.email
+	authors []string // Author emails of recent commits
+}
+
+// repoCluster groups repositories that appear to belong to the same identity
+type repoCluster struct {
+	alias string
+	repos []repoIdentity
+}
+
+// scanRoots returns the directories searched for repositories during detection
+func (c *Config) scanRoots() []string {
+	if len(c.ScanRoots) > 0 {
+		return c.ScanRoots
+	}
+	return DefaultScanRoots
+}
+
+// scanRepositories walks the scan roots and turns the repositories found into candidates
+func scanRepositories(roots []string, sshConfig *sshconfig.Config, sshMap map[string]string) []Candidate {
+	var identities []repoIdentity
+	for _, root := range roots {
+		paths, err := workspace.FindRepositories(ExpandHome(root))
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			if identity, ok := inspectRepoIdentity(path, sshConfig); ok {
+				identities = append(identities, identity)
+			}
+		}
+	}
+
+	var candidates []Candidate
+	for _, cluster := range clusterRepositories(identities) {
+		candidates = append(candidates, cluster.candidate(sshMap, roots))
+	}
+	return candidates
+}
+
+// isGitHubAlias reports whether a remote's host is an ssh alias for
+// github.com: ssh config sends it there, or it is named like the aliases ghmm
+// creates (github-work, github.com-work), which applying configs will add
+func isGitHubAlias(host string, sshConfig *sshconfig.Config) bool {
+	if sshConfig != nil && strings.EqualFold(sshConfig.Resolve(host).HostName, DefaultHost) {
+		return true
+	}
+	lower := strings.ToLower(host)
+	return strings.HasPrefix(lower, "github-") || strings.HasPrefix(lower, DefaultHost+"-")
+}
+
+// inspectRepoIdentity reads a repository's local config and recent history.
+// Repositories whose remotes are all on other hosts, such as GitLab or
+// GitHub Enterprise, are skipped; their identity isn't a github.com account.
+func inspectRepoIdentity(path string, sshConfig *sshconfig.Config) (repoIdentity, bool) {
+	identity := repoIdentity{path: path}
+	otherHosts := false
+
+	if file, err := gitconfig.ParseFile(filepath.Join(repoGitDir(path), "config")); err == nil {
+		for _, e := range file.Entries {
+			switch {
+			case e.Section == "remote" && e.Key == "url":
+				remote, ok := workspace.ParseRemote(e.Value)
+				if !ok {
+					continue
+				}
+				switch {
+				case strings.EqualFold(remote.Host, DefaultHost):
+				case isGitHubAlias(remote.Host, sshConfig):
+					if identity.alias == "" {
+						identity.alias = remote.Host
+					}
+				default:
+					otherHosts = true
+					continue
+				}
+				identity.owners = append(identity.owners, remote.Owner)
+			case e.Section == "user" && e.Subsection == "" && e.Key == "email":
+				identity.email = e.Value
+			}
+		}
+	}
+	if otherHosts && len(identity.owners) == 0 {
+		return identity, false
+	}
+
+	cmd := exec.Command("git", "-C", path, "log", "-n", fmt.Sprint(recentCommits), "--format=%ae")
+	if output, err := cmd.Output(); err == nil {
+		identity.authors = strings.Fields(string(output))
+	}
+
+	return identity, identity.alias != "" || identity.email != "" || len(identity.authors) > 0
+}
+
+// repoGitDir returns the git directory of a repository, following the
+// "gitdir:" pointer that worktrees and submodules use in place of a .git directory
+func repoGitDir(path string) string {
+	gitPath := filepath.Join(path, ".git")
+	data, err := os.ReadFile(gitPath)
+	if err != nil {
+		return gitPath
+	}
+	gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return gitPath
+	}
+	gitdir = strings.TrimSpace(gitdir)
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(path, gitdir)
+	}
+	return gitdir
+}
+
+// bestEmail returns the repository's best guess at the commit email in use
+func (r repoIdentity) bestEmail() string {
+	if r.email != "" {
+		return r.email
+	}
+	return mostCommon(r.authors)
+}
+
+// clusterRepositories groups repositories by host alias first, then by email.
+// Repositories without an alias join the alias cluster that commits with the same email.
+func clusterRepositories(identities []repoIdentity) []*repoCluster {
+	var clusters []*repoCluster
+	byAlias := map[string]*repoCluster{}
+	byEmail := map[string]*repoCluster{}
+
+	for _, identity := range identities {
+		if identity.alias == "" {
+			continue
+		}
+		cluster, ok := byAlias[identity.alias]
+		if !ok {
+			cluster = &repoCluster{alias: identity.alias}
+			byAlias[identity.alias] = cluster
+			clusters = append(clusters, cluster)
+		}
+		cluster.repos = append(cluster.repos, identity)
+	}
+
+	for _, cluster := range clusters {
+		if email := strings.ToLower(cluster.email()); email != "" {
+			if _, taken := byEmail[email]; !taken {
+				byEmail[email] = cluster
+			}
+		}
+	}
+
+	for _, identity := range identities {
+		if identity.alias != "" {
+			continue
+		}
+		email := strings.ToLower(identity.bestEmail())
+		if email == "" {
+			continue
+		}
+		cluster, ok := byEmail[email]
+		if !ok {
+			cluster = &repoCluster{}
+			byEmail[email] = cluster
+			clusters = append(clusters, cluster)
+		}
+		cluster.repos = append(cluster.repos, identity)
+	}
+
+	return clusters
+}
+
+// email returns the most common email across the cluster's repositories
+func (c *repoCluster) email() string {
+	var emails []string
+	for _, repo := range c.repos {
+		if email := repo.bestEmail(); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return mostCommon(emails)
+}
+
+// directory returns the deepest directory containing every repository in the cluster
+func (c *repoCluster) directory() string {
+	dir := filepath.Dir(c.repos[0].path)
+	for _, repo := range c.repos[1:] {
+		for !strings.HasPrefix(repo.path+string(filepath.Separator), dir+string(filepath.Separator)) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return dir
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// coversScanRoot reports whether dir is one of the scan roots or contains one;
+// such a directory holds other accounts' repositories too
+func coversScanRoot(dir string, roots []string) bool {
+	for _, root := range roots {
+		root = filepath.Clean(ExpandHome(root))
+		if dir == root || isWithin(root, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// candidate turns the cluster into an import candidate. When the cluster's
+// repositories only share a scan root, each repository becomes a directory
+// of its own rather than mapping the whole root to one account.
+func (c *repoCluster) candidate(sshMap map[string]string, roots []string) Candidate {
+	email := c.email()
+	directory := c.directory()
+
+	var owners []string
+	for _, repo := range c.repos {
+		owners = append(owners, repo.owners...)
+	}
+
+	directories := []string{directory}
+	name := filepath.Base(directory)
+	if coversScanRoot(directory, roots) {
+		directories = nil
+		for _, repo := range c.repos {
+			directories = append(directories, repo.path)
+		}
+		name, _, _ = strings.Cut(email, "@")
+		name = strings.ReplaceAll(name, "+", "-")
+		if name == "" {
+			name = filepath.Base(c.repos[0].path)
+		}
+	}
+	if c.alias != "" {
+		name = aliasSuffix(c.alias)
+	}
+
+	account := Account{
+		Name:        name,
+		Username:    usernameFromEmail(email, mostCommon(owners)),
+		Email:       email,
+		Directories: directories,
+		HostAlias:   c.alias,
+	}
+
+	noun := "repositories"
+	if len(c.repos) == 1 {
+		noun = "repository"
+	}
+	source := fmt.Sprintf("%d %s under %s", len(c.repos), noun, directory)
+	candidate := Candidate{Account: account, Source: source}
+
+	if c.alias != "" {
+		candidate.Source += " with remotes via " + c.alias
+		// An alias missing from ssh config is left unpaired; applying configs creates it
+		if keyPath, ok := sshMap[c.alias]; ok {
+			candidate.Account.SSHKeyPath = keyPath
+			candidate.Rule = "repository remotes use this alias"
+			candidate.Confidence = 85
+		}
+	}
+
+	return candidate
+}
+
+// usernameFromEmail extracts the GitHub username from a noreply address,
+// falling back to the given default
+func usernameFromEmail(email, fallback string) string {
+	// Format: 12345+username@users.noreply.github.com
+	if local, ok := strings.CutSuffix(email, "@users.noreply.github.com"); ok {
+		if plusIdx := strings.Index(local, "+"); plusIdx != -1 {
+			return local[plusIdx+1:]
+		}
+		return local
+	}
+	return fallback
+}
+
+// mostCommon returns the most frequent value, preferring the earliest on ties
+func mostCommon(values []string) string {
+	counts := map[string]int{}
+	for _, v := range values {
+		counts[v]++
+	}
+
+	best := ""
+	for _, v := range values {
+		if counts[v] > counts[best] {
+			best = v
+		}
+	}
+	return best
+}
+
+// mergeScanned adds scanned candidates that don't duplicate an identity
+// already found in .gitconfig, renaming any whose name is taken
+func mergeScanned(candidates, scanned []Candidate) []Candidate {
+	for _, candidate := range scanned {
+		if i := duplicateIndex(candidates, candidate); i != -1 {
+			// The remotes' owner fills in a username the gitconfig couldn't give
+			if existing := &candidates[i].Account; existing.Username == "" && candidate.Account.Username != "" {
+				existing.Username = candidate.Account.Username
+				candidates[i].Source += ", username from " + candidate.Source
+			}
+			continue
+		}
+		candidate.Account.Name = uniqueName(candidates, candidate.Account.Name)
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// uniqueName appends -2, -3, ... to name until no candidate uses it
+func uniqueName(candidates []Candidate, name string) string {
+	taken := map[string]bool{}
+	for _, candidate := range candidates {
+		taken[candidate.Account.Name] = true
+	}
+
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	return unique
+}
+
+// duplicateIndex returns the candidate a scanned candidate describes the same identity as, or -1.
+// A shared directory only counts when the host aliases agree too, or the
+// existing candidate's alias is still unknown, since two accounts' repositories
+// can sit in one directory.
+func duplicateIndex(candidates []Candidate, scanned Candidate) int {
+	for i, existing := range candidates {
+		sameAlias := existing.Account.HostAlias == "" || existing.Account.HostAlias == scanned.Account.HostAlias
+		if (scanned.Account.Email != "" && strings.EqualFold(existing.Account.Email, scanned.Account.Email)) ||
+			(sameAlias && slices.Contains(existing.Account.Directories, scanned.Account.PrimaryDirectory())) ||
+			(scanned.Account.HostAlias != "" && existing.Account.HostAlias == scanned.Account.HostAlias) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donbowman/github-multi-account-manager/internal/ssh/sshconfig"
+)
+
+// writeRepo creates a repository at root/name with just enough of a .git
+// directory for scanning: a config with the given remote and user.email
+func writeRepo(t *testing.T, root, name, remote, email string) {
+	t.Helper()
+	gitDir := filepath.Join(root, name, ".git")
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := "[remote \"origin\"]\n\turl = " + remote + "\n[user]\n\temail = " + email + "\n"
+	if err := os.WriteFile(filepath.Join(gitDir, "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanRepositoriesHosts(t *testing.T) {
+	root := t.TempDir()
+	// Keep git log from finding a repository above the fake ones
+	t.Setenv("GIT_CEILING_DIRECTORIES", root)
+
+	sshPath := filepath.Join(t.TempDir(), "config")
+	sshText := "Host gh-work\n    HostName github.com\n    IdentityFile ~/.ssh/id_work\n\nHost gitlab-work\n    HostName gitlab.com\n"
+	if err := os.WriteFile(sshPath, []byte(sshText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sshConfig, err := sshconfig.Load(sshPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeRepo(t, root, "work/api", "git@gh-work:acme/api.git", "me@acme.com")
+	writeRepo(t, root, "personal/dots", "git@github.com-personal:me/dots.git", "me@home.com")
+	writeRepo(t, root, "oss/tool", "https://github.com/me/tool.git", "me@oss.org")
+	writeRepo(t, root, "gitlab/proj", "git@gitlab.com:someone/proj.git", "me@gitlab.org")
+	writeRepo(t, root, "gitlab/aliased", "git@gitlab-work:someone/aliased.git", "me@gitlab.org")
+	writeRepo(t, root, "ghe/svc", "git@github.acme-corp.com:team/svc.git", "me@corp.com")
+
+	candidates := scanRepositories([]string{root}, sshConfig, parseSSHConfig(sshConfig))
+
+	want := map[string]string{ // email -> host alias
+		"me@acme.com": "gh-work",
+		"me@home.com": "github.com-personal",
+		"me@oss.org":  "",
+	}
+	if len(candidates) != len(want) {
+		t.Fatalf("got %d candidates, want %d: %+v", len(candidates), len(want), candidates)
+	}
+	for _, c := range candidates {
+		alias, ok := want[c.Account.Email]
+		if !ok {
+			t.Errorf("unexpected candidate %s (%s) from %s", c.Account.Name, c.Account.Email, c.Source)
+			continue
+		}
+		if c.Account.HostAlias != alias {
+			t.Errorf("%s host alias = %q, want %q", c.Account.Email, c.Account.HostAlias, alias)
+		}
+	}
+}
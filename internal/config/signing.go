@@ -0,0 +1,93 @@
+package config
+
+import "strings"
+
+// Signing modes for Signing.Mode
+const (
+	SigningNone = "none"
+	SigningSSH  = "ssh"
+	SigningGPG  = "gpg"
+	SigningX509 = "x509"
+)
+
+// Signing configures how an account signs commits and tags
+type Signing struct {
+	Mode string `yaml:"mode,omitempty"` // none, ssh, gpg or x509; empty means ssh
+	// Key is the SSH public key path, GPG key id or X.509 certificate id.
+	// SSH signing uses the account's signing key, then its SSH key, when it's empty.
+	Key string `yaml:"key,omitempty"`
+	// SignCommits and SignTags turn on commit.gpgsign and tag.gpgsign. When
+	// false git's global setting applies, unless the mode is none.
+	SignCommits bool `yaml:"sign_commits,omitempty"`
+	SignTags    bool `yaml:"sign_tags,omitempty"`
+}
+
+// gpgFormats maps signing modes to git's gpg.format
+var gpgFormats = map[string]string{
+	SigningSSH:  "ssh",
+	SigningGPG:  "openpgp",
+	SigningX509: "x509",
+}
+
+// SigningMode returns the account's signing mode, ssh when unset
+func (a Account) SigningMode() string {
+	if a.Signing.Mode == "" {
+		return SigningSSH
+	}
+	return a.Signing.Mode
+}
+
+// SigningKey returns the account's user.signingkey, or "" when it doesn't sign
+func (a Account) SigningKey() string {
+	switch {
+	case a.SigningMode() == SigningNone:
+		return ""
+	case a.Signing.Key != "":
+		return a.Signing.Key
+	case a.SigningMode() != SigningSSH:
+		return ""
+	case a.SigningKeyPath != "":
+		return a.SigningKeyPath + ".pub"
+	default:
+		return a.SSHKeyPath + ".pub"
+	}
+}
+
+// HasSeparateSigningKey reports whether the account signs with an SSH key of
+// its own rather than its authentication key
+func (a Account) HasSeparateSigningKey() bool {
+	return a.SigningKeyPath != ""
+}
+
+// DefaultSigningKeyPath returns where a separate signing key for the account is generated
+func (a Account) DefaultSigningKeyPath() string {
+	if a.SigningKeyPath != "" {
+		return a.SigningKeyPath
+	}
+	return strings.TrimSuffix(a.SSHKeyPath, "_ssh") + "_signing"
+}
+
+// GPGFormat returns git's gpg.format for the account, or "" when it doesn't sign
+func (a Account) GPGFormat() string {
+	return gpgFormats[a.SigningMode()]
+}
+
+// SigningDescription summarizes the account's signing setup for display
+func (a Account) SigningDescription() string {
+	if a.SigningMode() == SigningNone {
+		return "off"
+	}
+
+	var signs []string
+	if a.Signing.SignCommits {
+		signs = append(signs, "commits")
+	}
+	if a.Signing.SignTags {
+		signs = append(signs, "tags")
+	}
+	what := "signs when asked"
+	if len(signs) > 0 {
+		what = "signs " + strings.Join(signs, " and ")
+	}
+	return a.SigningMode() + " with " + a.SigningKey() + ", " + what
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FieldError describes why a single field of an account or the config is invalid
+type FieldError struct {
+	Account string // Account name, empty for config-level fields
+	Field   string // YAML field name, e.g. "directories"
+	Value   string
+	Reason  string
+	// Conflict is the other account involved in a rule that spans accounts
+	Conflict string
+}
+
+func (e *FieldError) Error() string {
+	if e.Account == "" {
+		return fmt.Sprintf("%s %q: %s", e.Field, e.Value, e.Reason)
+	}
+	return fmt.Sprintf("account '%s': %s %q: %s", e.Account, e.Field, e.Value, e.Reason)
+}
+
+// ValidationErrors is every problem found while validating
+type ValidationErrors []*FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, e := range v {
+		messages[i] = e.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// forAccount returns the errors that concern the named account, including
+// conflicts with it that are reported against another account
+func (v ValidationErrors) forAccount(name string) ValidationErrors {
+	var errs ValidationErrors
+	for _, e := range v {
+		if e.Account == name || e.Conflict == name {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// Field returns the first error for field, or nil
+func (v ValidationErrors) Field(field string) *FieldError {
+	for _, e := range v {
+		if e.Field == field {
+			return e
+		}
+	}
+	return nil
+}
+
+var (
+	// Account names end up in host aliases and file names like ~/.gitconfig-<name>
+	accountNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	// GitHub usernames, allowing the _shortcode suffix of managed users
+	usernameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*(_[A-Za-z0-9]+)?$`)
+	orgRe      = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+	hostRe     = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?(:[0-9]+)?$`)
+)
+
+// Validate checks each field of the account on its own
+func (a Account) Validate() error {
+	var errs ValidationErrors
+	fail := func(field, value, reason string) {
+		errs = append(errs, &FieldError{Account: a.Name, Field: field, Value: value, Reason: reason})
+	}
+
+	switch {
+	case a.Name == "":
+		fail("name", a.Name, "is required")
+	case !accountNameRe.MatchString(a.Name):
+		fail("name", a.Name, "may only contain letters, digits, '.', '_' and '-', and must start with a letter or digit")
+	}
+
+	switch {
+	case a.Username == "":
+		fail("username", a.Username, "is required")
+	case !usernameRe.MatchString(a.Username):
+		fail("username", a.Username, "is not a valid GitHub username")
+	}
+
+	if a.Email == "" {
+		fail("email", a.Email, "is required")
+	} else if addr, err := mail.ParseAddress(a.Email); err != nil || addr.Address != a.Email {
+		fail("email", a.Email, "is not a valid email address")
+	}
+
+	if len(a.Directories) == 0 && len(a.RemotePatterns) == 0 {
+		fail("directories", "", "at least one directory or remote pattern is required")
+	}
+	seen := map[string]bool{}
+	for _, dir := range a.Directories {
+		expanded := filepath.Clean(ExpandHome(dir))
+		switch {
+		case dir == "":
+			fail("directories", dir, "cannot be empty")
+		case !filepath.IsAbs(expanded):
+			fail("directories", dir, "must be an absolute path or start with ~/")
+		case filepath.Dir(expanded) == expanded:
+			fail("directories", dir, "cannot be the filesystem root")
+		case seen[expanded]:
+			fail("directories", dir, "is listed twice")
+		}
+		seen[expanded] = true
+	}
+
+	if a.SSHKeyPath == "" {
+		fail("ssh_key_path", a.SSHKeyPath, "is required")
+	}
+	if a.SigningKeyPath != "" && ExpandHome(a.SigningKeyPath) == ExpandHome(a.SSHKeyPath) {
+		fail("signing_key_path", a.SigningKeyPath, "must differ from ssh_key_path")
+	}
+
+	switch {
+	case a.HostAlias == "":
+		fail("host_alias", a.HostAlias, "is required")
+	case strings.ContainsAny(a.HostAlias, " \t*?!,"):
+		fail("host_alias", a.HostAlias, "cannot contain whitespace or ssh pattern characters")
+	}
+
+	for _, pattern := range a.RemotePatterns {
+		if err := validateRemotePattern(pattern); err != "" {
+			fail("remote_patterns", pattern, err)
+		}
+	}
+
+	orgs := map[string]bool{}
+	for _, org := range a.Orgs {
+		switch {
+		case !orgRe.MatchString(org):
+			fail("orgs", org, "is not a valid GitHub user or organization name")
+		case orgs[strings.ToLower(org)]:
+			fail("orgs", org, "is listed twice")
+		}
+		orgs[strings.ToLower(org)] = true
+	}
+
+	if a.Host != "" && !hostRe.MatchString(a.Host) {
+		fail("host", a.Host, "is not a valid host name")
+	}
+
+	switch a.SSHMode {
+	case "", SSHModeAlias:
+	case SSHModeCommand:
+		// core.sshCommand is run by the shell, so the key path is used unquoted
+		if strings.ContainsAny(a.SSHKeyPath, " \t'\"\\$`") {
+			fail("ssh_key_path", a.SSHKeyPath, "cannot contain whitespace, quotes or shell characters with ssh_mode command")
+		}
+	default:
+		fail("ssh_mode", a.SSHMode, "must be alias or command")
+	}
+
+	switch a.Signing.Mode {
+	case "", SigningSSH:
+	case SigningNone:
+		if a.Signing.SignCommits || a.Signing.SignTags {
+			fail("signing.mode", a.Signing.Mode, "is none, so commits and tags can't be signed")
+		}
+	case SigningGPG, SigningX509:
+		if a.Signing.Key == "" {
+			fail("signing.key", a.Signing.Key, "is required for gpg and x509 signing")
+		}
+	default:
+		fail("signing.mode", a.Signing.Mode, "must be none, ssh, gpg or x509")
+	}
+	if strings.ContainsAny(a.Signing.Key, "\"\n") {
+		fail("signing.key", a.Signing.Key, "cannot contain quotes or newlines")
+	}
+
+	switch a.GitProtocol {
+	case "", "ssh", "https":
+	default:
+		fail("git_protocol", a.GitProtocol, "must be ssh or https")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Validate checks every account and the rules that span accounts: unique
+// names, host aliases and directories, and a default that exists
+func (c *Config) Validate() error {
+	return validateAccounts(c.Accounts, c.DefaultAccount)
+}
+
+// validateChange validates a prospective set of accounts in which only the
+// named account changed, so problems elsewhere in a hand-edited file don't
+// block adding, editing or importing an account
+func validateChange(accounts []Account, defaultAccount, name string) error {
+	err := validateAccounts(accounts, defaultAccount)
+	if err == nil {
+		return nil
+	}
+	if errs := err.(ValidationErrors).forAccount(name); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateAccounts validates a set of accounts
+func validateAccounts(accounts []Account, defaultAccount string) error {
+	var errs ValidationErrors
+	names := map[string]bool{}
+	aliases := map[string]string{}
+	directories := map[string]string{}
+
+	for _, a := range accounts {
+		if err := a.Validate(); err != nil {
+			errs = append(errs, err.(ValidationErrors)...)
+		}
+
+		if names[a.Name] {
+			errs = append(errs, &FieldError{Account: a.Name, Field: "name", Value: a.Name, Reason: "is used by another account"})
+		}
+		names[a.Name] = true
+
+		if owner, ok := aliases[a.HostAlias]; ok && a.HostAlias != "" {
+			errs = append(errs, &FieldError{Account: a.Name, Field: "host_alias", Value: a.HostAlias,
+				Reason: fmt.Sprintf("is already used by account '%s'", owner), Conflict: owner})
+		} else {
+			aliases[a.HostAlias] = a.Name
+		}
+
+		for _, dir := range a.Directories {
+			dir = filepath.Clean(ExpandHome(dir))
+			// Nested directories are allowed; the most specific one wins (see DirectoryMappings)
+			if owner, ok := directories[dir]; ok && owner != a.Name {
+				errs = append(errs, &FieldError{Account: a.Name, Field: "directories", Value: dir,
+					Reason: fmt.Sprintf("is already used by account '%s'", owner), Conflict: owner})
+			} else {
+				directories[dir] = a.Name
+			}
+		}
+	}
+
+	if defaultAccount != "" && !names[defaultAccount] {
+		errs = append(errs, &FieldError{Field: "default_account", Value: defaultAccount, Reason: "does not match any account"})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// isWithin reports whether path is strictly inside dir
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
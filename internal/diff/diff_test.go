@@ -0,0 +1,62 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// format renders a diff as " a", "+b", "-c" lines for comparison
+func format(lines []Line) string {
+	prefixes := map[Op]string{Equal: " ", Insert: "+", Delete: "-"}
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(prefixes[line.Op] + line.Text + "\n")
+	}
+	return b.String()
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{"both empty", "", "", ""},
+		{"identical", "a\nb\n", "a\nb\n", " a\n b\n"},
+		{"trailing newline ignored", "a\nb", "a\nb\n\n", " a\n b\n"},
+		{"new file", "", "a\nb\n", "+a\n+b\n"},
+		{"removed file", "a\nb\n", "", "-a\n-b\n"},
+		{"insert in the middle", "a\nc\n", "a\nb\nc\n", " a\n+b\n c\n"},
+		{"delete in the middle", "a\nb\nc\n", "a\nc\n", " a\n-b\n c\n"},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", " a\n-b\n+B\n c\n"},
+		{"append", "a\n", "a\nb\n", " a\n+b\n"},
+		{"moved line", "a\nb\nc\n", "b\nc\na\n", "-a\n b\n c\n+a\n"},
+		{"blank lines kept", "a\n\nb\n", "a\nb\n", " a\n-\n b\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := format(Lines(tt.before, tt.after)); got != tt.want {
+				t.Errorf("Lines() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasChanges(t *testing.T) {
+	tests := []struct {
+		before, after string
+		want          bool
+	}{
+		{"", "", false},
+		{"a\nb\n", "a\nb", false},
+		{"a\n", "a\nb\n", true},
+		{"a\nb\n", "b\n", true},
+	}
+
+	for _, tt := range tests {
+		if got := HasChanges(Lines(tt.before, tt.after)); got != tt.want {
+			t.Errorf("HasChanges(Lines(%q, %q)) = %v, want %v", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// CredentialHelper is the helper command registered in each account's gitconfig
+const CredentialHelper = "!ghmm-cli credential"
+
+// Credential is a request from, or an answer to, git's credential helper protocol
+type Credential struct {
+	Protocol string
+	Host     string
+	Path     string // owner/repo.git, sent because useHttpPath is set
+	Username string
+	Password string
+}
+
+// Owner returns the repository owner from the credential's path
+func (c Credential) Owner() string {
+	owner, _, _ := strings.Cut(strings.TrimPrefix(c.Path, "/"), "/")
+	return owner
+}
+
+// ReadCredential parses the key=value lines git sends a credential helper,
+// up to a blank line or the end of input. Keys ghmm doesn't use are ignored.
+func ReadCredential(r io.Reader) (Credential, error) {
+	var c Credential
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return c, fmt.Errorf("failed to read credential: malformed line %q", line)
+		}
+		switch key {
+		case "protocol":
+			c.Protocol = value
+		case "host":
+			c.Host = value
+		case "path":
+			c.Path = value
+		case "username":
+			c.Username = value
+		case "password":
+			c.Password = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return c, fmt.Errorf("failed to read credential: %w", err)
+	}
+	return c, nil
+}
+
+// WriteCredential writes the username and password of an answer for git
+func WriteCredential(w io.Writer, c Credential) error {
+	if strings.ContainsAny(c.Username+c.Password, "\n\x00") {
+		return fmt.Errorf("failed to write credential: values cannot contain newlines")
+	}
+	var b strings.Builder
+	if c.Username != "" {
+		b.WriteString("username=" + c.Username + "\n")
+	}
+	if c.Password != "" {
+		b.WriteString("password=" + c.Password + "\n")
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write credential: %w", err)
+	}
+	return nil
+}
+
+// GHToken returns the token the gh CLI holds for username on host
+func GHToken(host, username string) (string, error) {
+	cmd := exec.Command("gh", "auth", "token", "--hostname", host, "--user", username)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("failed to get gh token for %s on %s: %s", username, host, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to get gh token for %s on %s: %w", username, host, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
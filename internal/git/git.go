@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/gitconfig"
+	"github.com/donbowman/github-multi-account-manager/internal/managed"
 )
 
 // Manager handles git configuration
@@ -28,44 +30,46 @@ func New() (*Manager, error) {
 	}, nil
 }
 
-// UpdateGitconfig updates main .gitconfig with includeIf directives
-func (m *Manager) UpdateGitconfig(accounts []config.Account) error {
-	const (
-		startMarker = "# BEGIN GHMM MANAGED GITCONFIG\n"
-		endMarker   = "# END GHMM MANAGED GITCONFIG\n"
-	)
+// Markers delimiting the section of ~/.gitconfig owned by ghmm
+const (
+	gitStartMarker = "# BEGIN GHMM MANAGED GITCONFIG\n"
+	gitEndMarker   = "# END GHMM MANAGED GITCONFIG\n"
+)
 
-	existingContent := ""
+// GitconfigPath returns the path to the main .gitconfig
+func (m *Manager) GitconfigPath() string {
+	return m.gitconfig
+}
 
-	// Read existing config
-	if data, err := os.ReadFile(m.gitconfig); err == nil {
-		content := string(data)
-
-		// Remove old ghmm section if it exists
-		if strings.Contains(content, startMarker) {
-			parts := strings.Split(content, startMarker)
-			before := parts[0]
-
-			afterParts := strings.Split(content, endMarker)
-			after := ""
-			if len(afterParts) > 1 {
-				after = afterParts[1]
-			}
-
-			existingContent = before + after
-		} else {
-			existingContent = content
-		}
-	}
+// AccountGitconfigPath returns the path to the gitconfig file for a specific account
+func (m *Manager) AccountGitconfigPath(accountName string) string {
+	return filepath.Join(m.ghmmConfigsDir, fmt.Sprintf(".gitconfig-%s", accountName))
+}
 
-	// Generate new includeIf sections
+// CurrentGitconfigSection returns the ghmm managed section currently in .gitconfig
+func (m *Manager) CurrentGitconfigSection() string {
+	return managed.Section(m.gitconfig, gitStartMarker, gitEndMarker)
+}
+
+// GenerateGitconfigSection renders the ghmm managed includeIf section for the
+// given accounts. allowedSigners is referenced for verifying SSH signatures
+// when any account signs with SSH.
+func (m *Manager) GenerateGitconfigSection(accounts []config.Account, allowedSigners string) string {
 	var newSection strings.Builder
-	newSection.WriteString(startMarker)
+	newSection.WriteString(gitStartMarker)
 	newSection.WriteString("# Generated by GitHub Multi-Account Manager\n\n")
 
-	for _, account := range accounts {
+	if allowedSigners != "" && signsWithSSH(accounts) {
+		newSection.WriteString("# Verify SSH signatures, e.g. git log --show-signature\n")
+		newSection.WriteString("[gpg \"ssh\"]\n")
+		newSection.WriteString(fmt.Sprintf("    allowedSignersFile = %s\n\n", allowedSigners))
+	}
+
+	// Git applies includes in order, so nested directories come last and win
+	for _, mapping := range config.DirectoryMappings(accounts) {
+		account := mapping.Account
 		// Ensure directory ends with /
-		directory := strings.TrimRight(account.Directory, "/") + "/"
+		directory := strings.TrimRight(mapping.Directory, "/") + "/"
 		configFile := fmt.Sprintf("~/.gitconfig-%s", account.Name)
 
 		newSection.WriteString(fmt.Sprintf("# %s account\n", account.Name))
@@ -73,10 +77,49 @@ func (m *Manager) UpdateGitconfig(accounts []config.Account) error {
 		newSection.WriteString(fmt.Sprintf("    path = %s\n\n", configFile))
 	}
 
-	newSection.WriteString(endMarker)
+	// Remote URL rules come last so they win over directories; git 2.36+ evaluates them
+	for _, account := range accounts {
+		globs := account.RemoteURLPatterns()
+		if len(globs) == 0 {
+			continue
+		}
+		configFile := fmt.Sprintf("~/.gitconfig-%s", account.Name)
+
+		newSection.WriteString(fmt.Sprintf("# %s account, by remote URL (git 2.36+)\n", account.Name))
+		for _, glob := range globs {
+			newSection.WriteString(fmt.Sprintf("[includeIf \"hasconfig:remote.*.url:%s\"]\n", glob))
+			newSection.WriteString(fmt.Sprintf("    path = %s\n", configFile))
+		}
+		newSection.WriteString("\n")
+	}
+
+	// Rewrites live here rather than in the per-account files because a clone
+	// runs outside any account directory, where includeIf gitdir never applies
+	rewrites, ambiguous := config.URLRewrites(accounts)
+	for _, rewrite := range rewrites {
+		newSection.WriteString(fmt.Sprintf("# %s account, %s repositories by any URL\n", rewrite.Account.Name, rewrite.Org))
+		newSection.WriteString(fmt.Sprintf("[url \"%s\"]\n", rewrite.Base))
+		for _, prefix := range rewrite.InsteadOf {
+			newSection.WriteString(fmt.Sprintf("    insteadOf = %s\n", prefix))
+		}
+		newSection.WriteString("\n")
+	}
+	for _, org := range ambiguous {
+		newSection.WriteString(fmt.Sprintf("# %s is listed by several accounts, so its URLs are not rewritten\n\n", org))
+	}
 
-	// Write updated config
-	finalContent := strings.TrimRight(existingContent, "\n") + "\n\n" + newSection.String()
+	newSection.WriteString(gitEndMarker)
+	return newSection.String()
+}
+
+// UpdateGitconfig updates main .gitconfig with includeIf directives
+func (m *Manager) UpdateGitconfig(accounts []config.Account, allowedSigners string) error {
+	// Read existing config, replacing any old ghmm section
+	existingContent := ""
+	if data, err := os.ReadFile(m.gitconfig); err == nil {
+		existingContent = string(data)
+	}
+	finalContent := managed.Replace(existingContent, gitStartMarker, gitEndMarker, m.GenerateGitconfigSection(accounts, allowedSigners))
 
 	if err := os.WriteFile(m.gitconfig, []byte(finalContent), 0644); err != nil {
 		return fmt.Errorf("failed to write gitconfig: %w", err)
@@ -85,29 +128,146 @@ func (m *Manager) UpdateGitconfig(accounts []config.Account) error {
 	return nil
 }
 
-// CreateAccountGitconfig creates a gitconfig file for a specific account
-func (m *Manager) CreateAccountGitconfig(account config.Account) error {
-	configFile := filepath.Join(m.ghmmConfigsDir, fmt.Sprintf(".gitconfig-%s", account.Name))
+// Markers delimiting the section of ~/.gitconfig-<name> owned by ghmm
+const (
+	accountStartMarker = "# BEGIN GHMM MANAGED ACCOUNT CONFIG\n"
+	accountEndMarker   = "# END GHMM MANAGED ACCOUNT CONFIG\n"
+)
+
+// legacyAccountKeys are the only settings ghmm wrote to per-account gitconfigs
+// before they had a managed section
+var legacyAccountKeys = map[string]bool{
+	"user.name":       true,
+	"user.email":      true,
+	"user.signingkey": true,
+	"gpg.format":      true,
+	"core.sshcommand": true,
+}
+
+// GenerateAccountGitconfig renders the ghmm managed section of a specific account's gitconfig
+func (m *Manager) GenerateAccountGitconfig(account config.Account) string {
+	var section strings.Builder
+	section.WriteString(accountStartMarker)
+	section.WriteString("# Generated by GitHub Multi-Account Manager\n")
+	section.WriteString("# Add your own settings outside this section; settings after it override it\n")
+	section.WriteString("[user]\n")
+	section.WriteString(fmt.Sprintf("    name = %s\n", account.Username))
+	section.WriteString(fmt.Sprintf("    email = %s\n", account.Email))
+	if key := account.SigningKey(); key != "" {
+		section.WriteString(fmt.Sprintf("    signingkey = %s\n", key))
+		section.WriteString(fmt.Sprintf("\n[gpg]\n    format = %s\n", account.GPGFormat()))
+	}
+
+	// Only turn signing on or, for mode none, off; otherwise the global setting applies
+	if account.SigningMode() == config.SigningNone {
+		section.WriteString("\n[commit]\n    gpgsign = false\n")
+		section.WriteString("\n[tag]\n    gpgsign = false\n")
+	} else {
+		if account.Signing.SignCommits {
+			section.WriteString("\n[commit]\n    gpgsign = true\n")
+		}
+		if account.Signing.SignTags {
+			section.WriteString("\n[tag]\n    gpgsign = true\n")
+		}
+	}
+
+	if account.UsesSSHCommand() {
+		section.WriteString(fmt.Sprintf(`
+[core]
+    sshCommand = %s
+`, account.SSHCommand()))
+	}
+
+	// HTTPS remotes get the token of the account that owns the repository;
+	// the empty helper drops helpers set earlier, such as gh's, for this host
+	if account.UsesHTTPS() {
+		section.WriteString(fmt.Sprintf(`
+[credential "https://%s"]
+    useHttpPath = true
+    helper =
+    helper = %s
+`, account.GitHubHost(), CredentialHelper))
+	}
+
+	section.WriteString(accountEndMarker)
+	return section.String()
+}
+
+// AccountGitconfigContent returns what the account's gitconfig file should
+// contain: the existing file with its managed section replaced, keeping
+// anything the user added around it
+func (m *Manager) AccountGitconfigContent(account config.Account) string {
+	section := m.GenerateAccountGitconfig(account)
+
+	data, err := os.ReadFile(m.AccountGitconfigPath(account.Name))
+	if err != nil {
+		return section
+	}
+	content := string(data)
+
+	start := strings.Index(content, accountStartMarker)
+	if start == -1 {
+		// Files from before the managed section that only hold what ghmm wrote are replaced outright
+		if isLegacyAccountGitconfig(content) {
+			return section
+		}
+		// Otherwise keep the user's settings and append, so ghmm's values win over stale ones
+		return strings.TrimRight(content, "\n") + "\n\n" + section
+	}
 
-	content := fmt.Sprintf(`[user]
-    name = %s
-    email = %s
-    signingkey = %s.pub
+	end := strings.Index(content[start:], accountEndMarker)
+	if end == -1 {
+		return content[:start] + section
+	}
+	return content[:start] + section + content[start+end+len(accountEndMarker):]
+}
 
-[gpg]
-    format = ssh
-`, account.Username, account.Email, account.SSHKeyPath)
+// isLegacyAccountGitconfig reports whether a per-account gitconfig without
+// markers contains nothing but the settings ghmm used to write
+func isLegacyAccountGitconfig(content string) bool {
+	entries, err := gitconfig.Parse(content, "")
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !legacyAccountKeys[e.Name()] {
+			return false
+		}
+	}
+	return true
+}
 
-	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+// CreateAccountGitconfig creates or updates the gitconfig file for a specific
+// account, rewriting only its managed section
+func (m *Manager) CreateAccountGitconfig(account config.Account) error {
+	configFile := m.AccountGitconfigPath(account.Name)
+
+	if err := os.WriteFile(configFile, []byte(m.AccountGitconfigContent(account)), 0644); err != nil {
 		return fmt.Errorf("failed to create account gitconfig: %w", err)
 	}
 
 	return nil
 }
 
+// RenameAccountGitconfig moves an account's gitconfig, and any settings added
+// to it, to the file for its new name
+func (m *Manager) RenameAccountGitconfig(oldName, newName string) error {
+	oldFile := m.AccountGitconfigPath(oldName)
+	newFile := m.AccountGitconfigPath(newName)
+
+	if _, err := os.Stat(newFile); err == nil {
+		return fmt.Errorf("failed to rename gitconfig: %s already exists", newFile)
+	}
+	if err := os.Rename(oldFile, newFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rename gitconfig: %w", err)
+	}
+
+	return nil
+}
+
 // RemoveAccountGitconfig removes a gitconfig file for a specific account
 func (m *Manager) RemoveAccountGitconfig(accountName string) error {
-	configFile := filepath.Join(m.ghmmConfigsDir, fmt.Sprintf(".gitconfig-%s", accountName))
+	configFile := m.AccountGitconfigPath(accountName)
 
 	if err := os.Remove(configFile); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove gitconfig: %w", err)
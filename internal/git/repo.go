@@ -0,0 +1,62 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/donbowman/github-multi-account-manager/internal/workspace"
+)
+
+// Repository describes a git repository found on disk
+type Repository struct {
+	Path        string
+	RemoteURL   string
+	Branch      string
+	CommitEmail string
+}
+
+// InspectRepository reads the origin remote, branch and effective commit email of a repository
+func InspectRepository(path string) Repository {
+	return Repository{
+		Path:        path,
+		RemoteURL:   gitOutput(path, "remote", "get-url", "origin"),
+		Branch:      gitOutput(path, "rev-parse", "--abbrev-ref", "HEAD"),
+		CommitEmail: gitOutput(path, "config", "user.email"),
+	}
+}
+
+// SetRemoteURL points a repository remote at a new URL
+func SetRemoteURL(path, remote, url string) error {
+	cmd := exec.Command("git", "-C", path, "remote", "set-url", remote, url)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set remote: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output, or "" on failure
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// SSHCloneURL returns the SSH URL for a remote on a host or host alias
+func SSHCloneURL(host string, remote workspace.Remote) string {
+	return fmt.Sprintf("git@%s:%s.git", host, remote.OwnerRepo())
+}
+
+// CloneCommand builds a git clone command that reports progress even when not
+// attached to a terminal. A non-empty sshCommand is used for the clone and
+// saved in the new repository's config.
+func CloneCommand(url, destination, sshCommand string) *exec.Cmd {
+	args := []string{"clone", "--progress"}
+	if sshCommand != "" {
+		args = append(args, "-c", "core.sshCommand="+sshCommand)
+	}
+	return exec.Command("git", append(args, url, destination)...)
+}
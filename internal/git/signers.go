@@ -0,0 +1,173 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/donbowman/github-multi-account-manager/internal/config"
+)
+
+// CurrentAllowedSigners returns the allowed_signers file as it is on disk
+func (m *Manager) CurrentAllowedSigners(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// signsWithSSH reports whether any account signs with an SSH key
+func signsWithSSH(accounts []config.Account) bool {
+	for _, account := range accounts {
+		if account.SigningMode() == config.SigningSSH {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAllowedSigners renders an allowed_signers file mapping each SSH
+// signing account's email to its public key, followed by the entries of
+// every imported signer file
+func (m *Manager) GenerateAllowedSigners(accounts []config.Account, signerFiles []string) string {
+	var b strings.Builder
+	b.WriteString("# Generated by GitHub Multi-Account Manager\n")
+	b.WriteString("# Do not edit; import extra signers with: ghmm-cli signers import <file>\n\n")
+
+	for _, account := range accounts {
+		if account.SigningMode() != config.SigningSSH {
+			continue
+		}
+		key, err := readSigningKey(account.SigningKey())
+		if err != nil {
+			b.WriteString(fmt.Sprintf("# %s account: %v\n\n", account.Name, err))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("# %s account\n", account.Name))
+		b.WriteString(fmt.Sprintf("%s namespaces=\"git\" %s\n\n", account.Email, key))
+	}
+
+	for _, path := range signerFiles {
+		entries, err := ParseSignersFile(path)
+		if err != nil {
+			b.WriteString(fmt.Sprintf("# %v\n\n", err))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("# From %s\n", path))
+		for _, entry := range entries {
+			b.WriteString(entry + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// UpdateAllowedSigners writes the allowed_signers file
+func (m *Manager) UpdateAllowedSigners(path string, accounts []config.Account, signerFiles []string) error {
+	if err := os.WriteFile(path, []byte(m.GenerateAllowedSigners(accounts, signerFiles)), 0644); err != nil {
+		return fmt.Errorf("failed to write allowed signers: %w", err)
+	}
+	return nil
+}
+
+// readSigningKey returns the public key for a user.signingkey value, which is
+// either a key:: literal or the path of a public key file
+func readSigningKey(signingKey string) (string, error) {
+	if key, ok := strings.CutPrefix(signingKey, "key::"); ok {
+		return strings.TrimSpace(key), nil
+	}
+	data, err := os.ReadFile(config.ExpandHome(signingKey))
+	if err != nil {
+		return "", fmt.Errorf("no public key at %s", signingKey)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ParseSignersFile reads the entries of an allowed_signers file, skipping
+// comments and blank lines, and fails on lines that have no public key
+func ParseSignersFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signers file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if !hasPublicKey(entry) {
+			return nil, fmt.Errorf("%s:%d: expected \"principals [options] keytype key\"", path, line)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read signers file: %w", err)
+	}
+	return entries, nil
+}
+
+// hasPublicKey reports whether an allowed_signers entry contains a key type followed by a key
+func hasPublicKey(entry string) bool {
+	fields := strings.Fields(entry)
+	for i, field := range fields[1:] {
+		if (strings.HasPrefix(field, "ssh-") || strings.HasPrefix(field, "ecdsa-") ||
+			strings.HasPrefix(field, "sk-")) && i+2 < len(fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitSignature is the signature status of one commit
+type CommitSignature struct {
+	Hash    string
+	Status  string // git's %G?: G good, U good but not an allowed signer, B bad, N none, E can't check, X/Y expired, R revoked
+	Signer  string // Principal or key owner that made the signature
+	Author  string // Author email
+	Subject string
+}
+
+// Good reports whether the commit carries a valid signature from an allowed signer
+func (s CommitSignature) Good() bool {
+	return s.Status == "G"
+}
+
+// VerifyCommits checks the signatures of the last n commits of the repository
+// at path against the given allowed_signers file
+func VerifyCommits(path, allowedSigners string, n int) ([]CommitSignature, error) {
+	cmd := exec.Command("git", "-C", path,
+		"-c", "gpg.ssh.allowedSignersFile="+allowedSigners,
+		"log", "-n", strconv.Itoa(n), "--format=%H%x00%G?%x00%GS%x00%ae%x00%s")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to read commits: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to read commits: %w", err)
+	}
+
+	var signatures []CommitSignature
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "\x00", 5)
+		if len(fields) < 5 {
+			continue
+		}
+		signatures = append(signatures, CommitSignature{
+			Hash:    fields[0],
+			Status:  fields[1],
+			Signer:  fields[2],
+			Author:  fields[3],
+			Subject: fields[4],
+		})
+	}
+	return signatures, nil
+}
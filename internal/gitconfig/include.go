@@ -0,0 +1,305 @@
+package gitconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxIncludeDepth matches git's own limit on nested includes
+const maxIncludeDepth = 10
+
+// Context describes the repository that conditional includes are evaluated against
+type Context struct {
+	GitDir     string   // Path to the repository's .git directory
+	Branch     string   // Checked out branch name, without refs/heads/
+	RemoteURLs []string // Remote URLs configured in the repository
+}
+
+// Include is an include or includeIf directive found in a config file
+type Include struct {
+	Condition string // Empty for an unconditional [include]
+	Path      string // Path as written in the config file
+	File      string // Config file containing the directive
+}
+
+// ResolvedPath expands ~/ and makes the include path absolute relative to its config file
+func (i Include) ResolvedPath() string {
+	return resolvePath(i.Path, i.File)
+}
+
+// Includes returns the include and includeIf directives in a file, in order
+func (f *File) Includes() []Include {
+	var includes []Include
+	for _, e := range f.Entries {
+		if e.Key != "path" {
+			continue
+		}
+		switch {
+		case e.Section == "include" && e.Subsection == "":
+			includes = append(includes, Include{Path: e.Value, File: f.Path})
+		case e.Section == "includeif" && e.Subsection != "":
+			includes = append(includes, Include{Condition: e.Subsection, Path: e.Value, File: f.Path})
+		}
+	}
+	return includes
+}
+
+// ConditionalIncludes returns every includeIf directive reachable from path,
+// following unconditional includes along the way
+func ConditionalIncludes(path string) ([]Include, error) {
+	var result []Include
+	err := walkIncludes(path, 0, map[string]bool{}, func(inc Include) {
+		result = append(result, inc)
+	})
+	return result, err
+}
+
+func walkIncludes(path string, depth int, seen map[string]bool, visit func(Include)) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("exceeded maximum include depth (%d) at %s", maxIncludeDepth, path)
+	}
+	if seen[path] {
+		return nil
+	}
+	seen[path] = true
+
+	file, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, inc := range file.Includes() {
+		if inc.Condition != "" {
+			visit(inc)
+			continue
+		}
+		// Missing include files are silently ignored, as git does
+		if err := walkIncludes(inc.ResolvedPath(), depth+1, seen, visit); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Config is the merged result of a config file and everything it includes
+type Config struct {
+	Entries []Entry
+}
+
+// Load reads path and the files it includes, evaluating includeIf conditions against ctx
+func Load(path string, ctx Context) (*Config, error) {
+	c := &Config{}
+	if err := c.load(path, ctx, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) load(path string, ctx Context, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("exceeded maximum include depth (%d) at %s", maxIncludeDepth, path)
+	}
+
+	file, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Included entries take effect at the position of the include, so
+	// anything after it in the including file still wins
+	for _, e := range file.Entries {
+		c.Entries = append(c.Entries, e)
+
+		if e.Key != "path" {
+			continue
+		}
+		include := e.Section == "include" && e.Subsection == ""
+		if e.Section == "includeif" && e.Subsection != "" {
+			include = c.Matches(e.Subsection, ctx, path)
+		}
+		if !include {
+			continue
+		}
+
+		if err := c.load(resolvePath(e.Value, path), ctx, depth+1); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the last value of a variable such as "user.email" or "remote.origin.url"
+func (c *Config) Get(name string) (string, bool) {
+	values := c.GetAll(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns every value of a multi-valued variable in the order they were read
+func (c *Config) GetAll(name string) []string {
+	section, subsection, key := splitName(name)
+	var values []string
+	for _, e := range c.Entries {
+		if e.Section == section && e.Subsection == subsection && e.Key == key {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+// Matches evaluates an includeIf condition, e.g. "gitdir:~/work/", against ctx.
+// configPath is the file containing the condition, used for relative patterns.
+func (c *Config) Matches(condition string, ctx Context, configPath string) bool {
+	kind, pattern, ok := strings.Cut(condition, ":")
+	if !ok {
+		return false
+	}
+
+	switch kind {
+	case "gitdir", "gitdir/i":
+		if ctx.GitDir == "" {
+			return false
+		}
+		return MatchGitdir(pattern, ctx.GitDir, configPath, kind == "gitdir/i")
+
+	case "onbranch":
+		if ctx.Branch == "" {
+			return false
+		}
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return wildmatch(pattern, ctx.Branch, false)
+
+	case "hasconfig":
+		urlPattern, ok := strings.CutPrefix(pattern, "remote.*.url:")
+		if !ok {
+			return false
+		}
+		urls := append([]string{}, ctx.RemoteURLs...)
+		for _, e := range c.Entries {
+			if e.Section == "remote" && e.Key == "url" {
+				urls = append(urls, e.Value)
+			}
+		}
+		for _, url := range urls {
+			if MatchURL(urlPattern, url) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MatchGitdir reports whether gitDir matches a gitdir: pattern using git's rules:
+// ~/ and ./ are expanded, patterns not anchored at / get a leading **/,
+// and a trailing / matches everything below the directory
+func MatchGitdir(pattern, gitDir, configPath string, foldCase bool) bool {
+	switch {
+	case strings.HasPrefix(pattern, "~/"):
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = filepath.Join(home, pattern[2:]) + trailingSlash(pattern)
+		}
+	case strings.HasPrefix(pattern, "./"):
+		pattern = filepath.Join(filepath.Dir(configPath), pattern[2:]) + trailingSlash(pattern)
+	case !filepath.IsAbs(pattern):
+		pattern = "**/" + pattern
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	return wildmatch(pattern, filepath.ToSlash(gitDir), foldCase)
+}
+
+// MatchURL reports whether a remote URL matches the pattern of a
+// hasconfig:remote.*.url: condition
+func MatchURL(pattern, url string) bool {
+	return wildmatch(pattern, url, false)
+}
+
+func trailingSlash(pattern string) string {
+	if strings.HasSuffix(pattern, "/") {
+		return "/"
+	}
+	return ""
+}
+
+// wildmatch implements git's pathname globbing: * and ? stop at /, ** crosses directories
+func wildmatch(pattern, subject string, foldCase bool) bool {
+	var re strings.Builder
+	if foldCase {
+		re.WriteString("(?i)")
+	}
+	re.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	matched, err := regexp.MatchString(re.String(), subject)
+	return err == nil && matched
+}
+
+// splitName splits "section.subsection.key" into its canonical parts
+func splitName(name string) (section, subsection, key string) {
+	first := strings.Index(name, ".")
+	last := strings.LastIndex(name, ".")
+	if first == -1 {
+		return strings.ToLower(name), "", ""
+	}
+	section = strings.ToLower(name[:first])
+	key = strings.ToLower(name[last+1:])
+	if first != last {
+		subsection = name[first+1 : last]
+	}
+	return section, subsection, key
+}
+
+// resolvePath expands ~/ and resolves relative include paths against the including file
+func resolvePath(path, configPath string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(filepath.Dir(configPath), path)
+	}
+	return path
+}
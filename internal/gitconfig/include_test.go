@@ -0,0 +1,219 @@
+package gitconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWildmatch(t *testing.T) {
+	tests := []struct {
+		pattern, subject string
+		foldCase         bool
+		want             bool
+	}{
+		{"/src/*/.git", "/src/repo/.git", false, true},
+		{"/src/*/.git", "/src/a/b/.git", false, false},
+		{"/src/**/.git", "/src/a/b/.git", false, true},
+		{"/src/**/.git", "/src/.git", false, true},
+		{"**/work/**", "/home/alice/work/repo/.git", false, true},
+		{"**/work/**", "/home/alice/workshop/repo/.git", false, false},
+		{"/src/**", "/src/a/b", false, true},
+		{"/src/re?o", "/src/repo", false, true},
+		{"/src/re?o", "/src/re/o", false, false},
+		{"/src/[abc]", "/src/b", false, true},
+		{"/src/[!abc]", "/src/b", false, false},
+		{"/src/[!abc]", "/src/d", false, true},
+		{"/src/[abc", "/src/[abc", false, true},
+		{"/src/a.b", "/src/axb", false, false},
+		{"/Work/**", "/work/repo", false, false},
+		{"/Work/**", "/work/repo", true, true},
+	}
+
+	for _, tt := range tests {
+		if got := wildmatch(tt.pattern, tt.subject, tt.foldCase); got != tt.want {
+			t.Errorf("wildmatch(%q, %q, %v) = %v, want %v", tt.pattern, tt.subject, tt.foldCase, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGitdir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configPath := filepath.Join("/etc", "ghmm", "config")
+
+	tests := []struct {
+		pattern, gitDir string
+		foldCase        bool
+		want            bool
+	}{
+		{"~/work/", filepath.Join(home, "work", "repo", ".git"), false, true},
+		{"~/work/", filepath.Join(home, "personal", "repo", ".git"), false, false},
+		{"~/work/repo/.git", filepath.Join(home, "work", "repo", ".git"), false, true},
+		{"./repos/", "/etc/ghmm/repos/a/.git", false, true},
+		{"./repos/", "/etc/repos/a/.git", false, false},
+		{"work/", "/anywhere/work/repo/.git", false, true},
+		{"work/", "/anywhere/homework/repo/.git", false, false},
+		{"/srv/work/", "/srv/work/repo/.git", false, true},
+		{"/srv/work/", "/srv/workshop/repo/.git", false, false},
+		{"/srv/work", "/srv/work/repo/.git", false, false},
+		{"/srv/*/.git", "/srv/repo/.git", false, true},
+		{"/SRV/Work/", "/srv/work/repo/.git", false, false},
+		{"/SRV/Work/", "/srv/work/repo/.git", true, true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGitdir(tt.pattern, tt.gitDir, configPath, tt.foldCase); got != tt.want {
+			t.Errorf("MatchGitdir(%q, %q) = %v, want %v", tt.pattern, tt.gitDir, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	c := &Config{Entries: []Entry{
+		{Section: "remote", Subsection: "upstream", Key: "url", Value: "git@github.com:acme/tools.git"},
+	}}
+	ctx := Context{
+		GitDir:     "/srv/work/repo/.git",
+		Branch:     "feature/login",
+		RemoteURLs: []string{"https://github.com/alice/dotfiles.git"},
+	}
+
+	tests := []struct {
+		condition string
+		ctx       Context
+		want      bool
+	}{
+		{"gitdir:/srv/work/", ctx, true},
+		{"gitdir:/srv/other/", ctx, false},
+		{"gitdir/i:/SRV/WORK/", ctx, true},
+		{"gitdir:/srv/work/", Context{}, false},
+		{"onbranch:feature/", ctx, true},
+		{"onbranch:feature/*", ctx, true},
+		{"onbranch:main", ctx, false},
+		{"onbranch:feature/", Context{}, false},
+		{"hasconfig:remote.*.url:https://github.com/alice/**", ctx, true},
+		{"hasconfig:remote.*.url:git@github.com:acme/*", ctx, true},
+		{"hasconfig:remote.*.url:git@github.com:acme/*", Context{}, true},
+		{"hasconfig:remote.*.url:https://github.com/bob/**", ctx, false},
+		{"hasconfig:remote.*.url:*github.com*", ctx, false},
+		{"hasconfig:remote.*.url:**github.com**", ctx, true},
+		{"hasconfig:user.email:alice@example.com", ctx, false},
+		{"unknown:value", ctx, false},
+		{"nocolon", ctx, false},
+	}
+
+	for _, tt := range tests {
+		if got := c.Matches(tt.condition, tt.ctx, "/etc/gitconfig"); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "gitconfig")
+	writeConfig(t, main, `[user]
+	name = Default
+	email = default@example.com
+[include]
+	path = common.inc
+	path = missing.inc
+[includeIf "gitdir:`+dir+`/work/"]
+	path = accounts/work.inc
+[includeIf "gitdir:`+dir+`/personal/"]
+	path = accounts/personal.inc
+[user]
+	name = Override
+`)
+	writeConfig(t, filepath.Join(dir, "common.inc"), "[core]\n\tautocrlf = input\n")
+	writeConfig(t, filepath.Join(dir, "accounts", "work.inc"), "[user]\n\temail = work@example.com\n\tname = Work\n")
+	writeConfig(t, filepath.Join(dir, "accounts", "personal.inc"), "[user]\n\temail = me@example.com\n")
+
+	c, err := Load(main, Context{GitDir: filepath.Join(dir, "work", "repo", ".git")})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name, want string
+	}{
+		{"user.email", "work@example.com"},
+		// Entries after the include still win
+		{"user.name", "Override"},
+		{"core.autocrlf", "input"},
+		{"CORE.AutoCRLF", "input"},
+	}
+	for _, tt := range tests {
+		if got, ok := c.Get(tt.name); !ok || got != tt.want {
+			t.Errorf("Get(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+
+	if got := c.GetAll("user.name"); strings.Join(got, ",") != "Default,Work,Override" {
+		t.Errorf("GetAll(user.name) = %q, want [Default Work Override]", got)
+	}
+	if _, ok := c.Get("user.signingkey"); ok {
+		t.Error("Get(user.signingkey) found a value, want none")
+	}
+}
+
+func TestLoadIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "gitconfig")
+	writeConfig(t, main, "[include]\n\tpath = gitconfig\n")
+
+	if _, err := Load(main, Context{}); err == nil || !strings.Contains(err.Error(), "maximum include depth") {
+		t.Errorf("Load() error = %v, want maximum include depth error", err)
+	}
+}
+
+func TestConditionalIncludes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	main := filepath.Join(home, ".gitconfig")
+	writeConfig(t, main, `[includeIf "gitdir:~/work/"]
+	path = ~/.config/work.inc
+[include]
+	path = .config/shared.inc
+	path = .gitconfig
+`)
+	writeConfig(t, filepath.Join(home, ".config", "shared.inc"), `[includeIf "onbranch:release/"]
+	path = release.inc
+[include]
+	path = ../.gitconfig
+`)
+
+	includes, err := ConditionalIncludes(main)
+	if err != nil {
+		t.Fatalf("ConditionalIncludes() error = %v", err)
+	}
+
+	want := []struct {
+		condition, resolved string
+	}{
+		{"gitdir:~/work/", filepath.Join(home, ".config", "work.inc")},
+		{"onbranch:release/", filepath.Join(home, ".config", "release.inc")},
+	}
+	if len(includes) != len(want) {
+		t.Fatalf("ConditionalIncludes() = %+v, want %d includes", includes, len(want))
+	}
+	for i, w := range want {
+		if includes[i].Condition != w.condition || includes[i].ResolvedPath() != w.resolved {
+			t.Errorf("include %d = %s -> %s, want %s -> %s",
+				i, includes[i].Condition, includes[i].ResolvedPath(), w.condition, w.resolved)
+		}
+	}
+}
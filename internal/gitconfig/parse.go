@@ -0,0 +1,292 @@
+package gitconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Entry is a single key/value pair from a gitconfig file
+type Entry struct {
+	Section    string // Lowercased section name, e.g. "user"
+	Subsection string // Case-sensitive subsection, e.g. the "gitdir:~/work/" of includeIf
+	Key        string // Lowercased variable name, e.g. "email"
+	Value      string
+	File       string // File the entry was read from
+	Line       int
+}
+
+// Name returns the canonical dotted name of the entry, e.g. "includeif.gitdir:~/work/.path"
+func (e Entry) Name() string {
+	if e.Subsection == "" {
+		return e.Section + "." + e.Key
+	}
+	return e.Section + "." + e.Subsection + "." + e.Key
+}
+
+// File is the parsed contents of a single gitconfig file, without includes resolved
+type File struct {
+	Path    string
+	Entries []Entry
+}
+
+// ParseFile reads and parses a single gitconfig file
+func ParseFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := Parse(string(data), path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{Path: path, Entries: entries}, nil
+}
+
+// parser walks gitconfig text one character at a time
+type parser struct {
+	data       string
+	pos        int
+	line       int
+	file       string
+	section    string
+	subsection string
+}
+
+// Parse parses gitconfig text. file is only used for error messages and Entry.File.
+func Parse(data, file string) ([]Entry, error) {
+	p := &parser{data: data, line: 1, file: file}
+	var entries []Entry
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return entries, nil
+		}
+
+		switch c := p.peek(); {
+		case c == '\n':
+			p.next()
+		case c == '#' || c == ';':
+			p.skipLine()
+		case c == '[':
+			if err := p.parseSection(); err != nil {
+				return nil, err
+			}
+		case isKeyStart(c):
+			entry, err := p.parseEntry()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		default:
+			return nil, p.errorf("unexpected character %q", c)
+		}
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *parser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.data[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipSpace skips blanks but not newlines
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r') {
+		p.pos++
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.file, p.line, fmt.Sprintf(format, args...))
+}
+
+func isKeyStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isKeyChar(c byte) bool {
+	return isKeyStart(c) || (c >= '0' && c <= '9') || c == '-'
+}
+
+// parseSection parses [section], [section "subsection"] and the legacy [section.subsection]
+func (p *parser) parseSection() error {
+	p.next() // [
+
+	start := p.pos
+	for !p.eof() && (isKeyChar(p.peek()) || p.peek() == '.') {
+		p.pos++
+	}
+	name := p.data[start:p.pos]
+	if name == "" {
+		return p.errorf("missing section name")
+	}
+
+	p.section = strings.ToLower(name)
+	p.subsection = ""
+
+	// Legacy [section.subsection] syntax lowercases the subsection
+	if dot := strings.Index(p.section, "."); dot != -1 {
+		p.subsection = p.section[dot+1:]
+		p.section = p.section[:dot]
+	}
+
+	p.skipSpace()
+	if !p.eof() && p.peek() == '"' {
+		p.next()
+		var sub strings.Builder
+		for {
+			if p.eof() || p.peek() == '\n' {
+				return p.errorf("unterminated subsection name")
+			}
+			c := p.next()
+			if c == '"' {
+				break
+			}
+			if c == '\\' && !p.eof() && p.peek() != '\n' {
+				c = p.next()
+			}
+			sub.WriteByte(c)
+		}
+		p.subsection = sub.String()
+		p.skipSpace()
+	}
+
+	if p.eof() || p.peek() != ']' {
+		return p.errorf("malformed section header")
+	}
+	p.next()
+	return nil
+}
+
+// parseEntry parses "key = value" or a bare "key", which means true
+func (p *parser) parseEntry() (Entry, error) {
+	if p.section == "" {
+		return Entry{}, p.errorf("key outside of a section")
+	}
+
+	line := p.line
+	start := p.pos
+	for !p.eof() && isKeyChar(p.peek()) {
+		p.pos++
+	}
+	key := strings.ToLower(p.data[start:p.pos])
+
+	entry := Entry{
+		Section:    p.section,
+		Subsection: p.subsection,
+		Key:        key,
+		File:       p.file,
+		Line:       line,
+	}
+
+	p.skipSpace()
+	if p.eof() || p.peek() == '\n' || p.peek() == '#' || p.peek() == ';' {
+		entry.Value = "true"
+		p.skipLine()
+		return entry, nil
+	}
+
+	if p.peek() != '=' {
+		return Entry{}, p.errorf("expected '=' after %q", key)
+	}
+	p.next()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Value = value
+	return entry, nil
+}
+
+// parseValue reads a value up to the end of the line, handling quotes,
+// escapes, inline comments and backslash line continuations
+func (p *parser) parseValue() (string, error) {
+	var value strings.Builder
+	inQuotes := false
+	// Whitespace is only kept when something other than whitespace follows it
+	pendingSpace := ""
+
+	p.skipSpace()
+	for !p.eof() {
+		c := p.next()
+		switch {
+		case c == '\n':
+			if inQuotes {
+				return "", p.errorf("unterminated quoted value")
+			}
+			return value.String(), nil
+
+		case !inQuotes && (c == '#' || c == ';'):
+			p.skipLine()
+			return value.String(), nil
+
+		case !inQuotes && (c == ' ' || c == '\t' || c == '\r'):
+			pendingSpace += string(c)
+
+		case c == '"':
+			value.WriteString(pendingSpace)
+			pendingSpace = ""
+			inQuotes = !inQuotes
+
+		case c == '\\':
+			if p.eof() {
+				return "", p.errorf("trailing backslash")
+			}
+			value.WriteString(pendingSpace)
+			pendingSpace = ""
+			switch e := p.next(); e {
+			case '\n':
+				// Line continuation
+			case '\r':
+				if !p.eof() && p.peek() == '\n' {
+					p.next()
+				}
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'b':
+				s := value.String()
+				if len(s) > 0 {
+					value.Reset()
+					value.WriteString(s[:len(s)-1])
+				}
+			case '"', '\\':
+				value.WriteByte(e)
+			default:
+				return "", p.errorf("invalid escape sequence \\%c", e)
+			}
+
+		default:
+			value.WriteString(pendingSpace)
+			pendingSpace = ""
+			value.WriteByte(c)
+		}
+	}
+
+	if inQuotes {
+		return "", p.errorf("unterminated quoted value")
+	}
+	return value.String(), nil
+}
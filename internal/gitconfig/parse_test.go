@@ -0,0 +1,147 @@
+package gitconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string // Entry.Name() + "=" + Entry.Value
+	}{
+		{
+			name: "simple section",
+			data: "[user]\n\tname = Alice\n\temail = alice@example.com\n",
+			want: []string{"user.name=Alice", "user.email=alice@example.com"},
+		},
+		{
+			name: "section and key names are case-insensitive",
+			data: "[User]\n\tEMail = a@example.com\n",
+			want: []string{"user.email=a@example.com"},
+		},
+		{
+			name: "quoted subsection keeps its case",
+			data: "[Remote \"Origin\"]\n\turl = git@github.com:a/b.git\n",
+			want: []string{"remote.Origin.url=git@github.com:a/b.git"},
+		},
+		{
+			name: "legacy dotted subsection is lowercased",
+			data: "[Remote.Origin]\n\turl = x\n",
+			want: []string{"remote.origin.url=x"},
+		},
+		{
+			name: "subsection escapes",
+			data: "[includeIf \"gitdir:~/a\\\"b\\\\c/\"]\n\tpath = x\n",
+			want: []string{`includeif.gitdir:~/a"b\c/.path=x`},
+		},
+		{
+			name: "bare key means true",
+			data: "[core]\n\tbare\n\tfilemode ; comment\n",
+			want: []string{"core.bare=true", "core.filemode=true"},
+		},
+		{
+			name: "comments and blank lines",
+			data: "# leading\n; also\n\n[a]\n\n\tk = v # trailing\n\tl = w;x\n",
+			want: []string{"a.k=v", "a.l=w"},
+		},
+		{
+			name: "inner whitespace kept, outer trimmed",
+			data: "[a]\n\tk =   one  two   \n",
+			want: []string{"a.k=one  two"},
+		},
+		{
+			name: "quoted value keeps whitespace and comment characters",
+			data: "[a]\n\tk = \"  #not;comment  \"\n",
+			want: []string{"a.k=  #not;comment  "},
+		},
+		{
+			name: "quotes in the middle of a value",
+			data: "[a]\n\tk = pre\" mid \"post\n",
+			want: []string{"a.k=pre mid post"},
+		},
+		{
+			name: "escapes",
+			data: "[a]\n\tk = \"q\\\"\\\\\" n\\n t\\t b\\b\n",
+			want: []string{"a.k=q\"\\ n\n t\t "},
+		},
+		{
+			name: "line continuation",
+			data: "[a]\n\tk = one \\\n\ttwo\n\tl = x\n",
+			want: []string{"a.k=one \ttwo", "a.l=x"},
+		},
+		{
+			name: "CRLF line continuation",
+			data: "[a]\r\n\tk = one\\\r\ntwo\r\n",
+			want: []string{"a.k=onetwo"},
+		},
+		{
+			name: "multiple sections and repeated keys",
+			data: "[a]\nk = 1\n[b \"s\"]\nk = 2\n[a]\nk = 3\n",
+			want: []string{"a.k=1", "b.s.k=2", "a.k=3"},
+		},
+		{
+			name: "value without trailing newline",
+			data: "[a]\nk = v",
+			want: []string{"a.k=v"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Parse(tt.data, "test")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name()+"="+e.Value)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLineNumbers(t *testing.T) {
+	entries, err := Parse("[a]\nk = one \\\ntwo\n\n# c\nl = x\n", "test")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Line != 2 || entries[1].Line != 6 {
+		t.Errorf("Parse() = %+v, want entries on lines 2 and 6", entries)
+	}
+	if entries[0].File != "test" {
+		t.Errorf("Entry.File = %q, want %q", entries[0].File, "test")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"key outside section", "k = v\n", "test:1: key outside of a section"},
+		{"empty section name", "[]\n", "missing section name"},
+		{"unterminated section", "[a\nk = v\n", "malformed section header"},
+		{"unterminated subsection", "[a \"b]\n", "unterminated subsection name"},
+		{"missing equals", "[a]\nk v\n", "test:2: expected '=' after \"k\""},
+		{"unterminated quote", "[a]\nk = \"v\nl = w\n", "unterminated quoted value"},
+		{"unterminated quote at EOF", "[a]\nk = \"v", "unterminated quoted value"},
+		{"invalid escape", "[a]\nk = \\x\n", "invalid escape sequence \\x"},
+		{"trailing backslash", "[a]\nk = v\\", "trailing backslash"},
+		{"unexpected character", "[a]\n=v\n", "unexpected character '='"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data, "test")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
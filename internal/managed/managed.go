@@ -0,0 +1,55 @@
+package managed
+
+import (
+	"os"
+	"strings"
+)
+
+// Section returns the part of the file at path that ghmm manages, from the
+// start marker through the end marker. It is empty when the file or the start
+// marker is missing, and runs to the end of the file when the end marker is.
+func Section(path, startMarker, endMarker string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	content := string(data)
+	start := strings.Index(content, startMarker)
+	if start == -1 {
+		return ""
+	}
+
+	end := strings.Index(content[start:], endMarker)
+	if end == -1 {
+		return content[start:]
+	}
+
+	return content[start : start+end+len(endMarker)]
+}
+
+// Replace returns content with the ghmm managed sections removed and section
+// appended at the end. Each removed section runs from a start marker through
+// the first end marker after it, or to the end of the file when that is
+// missing, so text after a stray marker is kept.
+func Replace(content, startMarker, endMarker, section string) string {
+	for {
+		start := strings.Index(content, startMarker)
+		if start == -1 {
+			break
+		}
+
+		end := strings.Index(content[start:], endMarker)
+		if end == -1 {
+			content = content[:start]
+			break
+		}
+		content = content[:start] + content[start+end+len(endMarker):]
+	}
+
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return section
+	}
+	return content + "\n\n" + section
+}
@@ -0,0 +1,72 @@
+package managed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	start = "# BEGIN GHMM\n"
+	end   = "# END GHMM\n"
+)
+
+func TestSection(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no markers", "user config\n", ""},
+		{"section in the middle", "before\n" + start + "managed\n" + end + "after\n", start + "managed\n" + end},
+		{"missing end marker", "before\n" + start + "managed\nrest\n", start + "managed\nrest\n"},
+		{"stray end marker before start", end + "before\n" + start + "managed\n" + end, start + "managed\n" + end},
+		{"second section ignored", start + "one\n" + end + start + "two\n" + end, start + "one\n" + end},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := Section(path, start, end); got != tt.want {
+				t.Errorf("Section() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSectionMissingFile(t *testing.T) {
+	if got := Section(filepath.Join(t.TempDir(), "missing"), start, end); got != "" {
+		t.Errorf("Section() = %q, want empty", got)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	section := start + "new\n" + end
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty file", "", section},
+		{"no existing section", "user config\n\n\n", "user config\n\n" + section},
+		{"existing section moves to the end", "before\n" + start + "old\n" + end + "after\n", "before\nafter\n\n" + section},
+		{"stray end marker after section", "before\n" + start + "old\n" + end + "keep\n" + end + "also keep\n",
+			"before\nkeep\n" + end + "also keep\n\n" + section},
+		{"stray end marker before section", end + "before\n" + start + "old\n" + end, end + "before\n\n" + section},
+		{"duplicate sections", start + "one\n" + end + "middle\n" + start + "two\n" + end, "middle\n\n" + section},
+		{"missing end marker", "before\n" + start + "old\n", "before\n\n" + section},
+		{"only the section", start + "old\n" + end, section},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Replace(tt.content, start, end, section); got != tt.want {
+				t.Errorf("Replace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// PassphraseEnv names the environment variable that supplies the vault
+// passphrase non-interactively, e.g. from a password manager
+const PassphraseEnv = "GHMM_VAULT_PASSPHRASE"
+
+// ErrNoTerminal is returned when a secret has to be typed but there is no
+// terminal to type it on
+var ErrNoTerminal = errors.New("no terminal")
+
+// Passphrase returns the vault passphrase from PassphraseEnv or, failing that,
+// asks for it on the terminal. confirm asks twice, for creating a vault.
+func Passphrase(confirm bool) ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	passphrase, err := readHidden("Vault passphrase: ")
+	if errors.Is(err, ErrNoTerminal) {
+		return nil, fmt.Errorf("%w to ask for the vault passphrase; set %s", err, PassphraseEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		again, err := readHidden("Repeat passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if string(again) != string(passphrase) {
+			return nil, fmt.Errorf("passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
+
+// ReadSecret reads a secret typed on the terminal without echoing it
+func ReadSecret(prompt string) ([]byte, error) {
+	return readHidden(prompt)
+}
+
+// readHidden prompts for a line and reads it without echoing it. Stdin is
+// used when it is a terminal, which works on every platform. Otherwise the
+// controlling terminal is opened directly, so prompting still works when
+// stdin is a pipe, as it is for git credential helpers; Windows has no
+// /dev/tty, so there the secret must come from stdin or the environment.
+func readHidden(prompt string) ([]byte, error) {
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		return readPassword(fd, os.Stderr, prompt)
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, ErrNoTerminal
+	}
+	defer tty.Close()
+	return readPassword(int(tty.Fd()), tty, prompt)
+}
+
+// readPassword prompts on out and reads a line from the terminal fd without echoing it
+func readPassword(fd int, out io.Writer, prompt string) ([]byte, error) {
+	fmt.Fprint(out, prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from terminal: %w", err)
+	}
+	return secret, nil
+}
+
+// ReadToken reads a token piped on stdin or, when stdin is a terminal, typed
+// without echoing it, so tokens never have to appear on the command line
+func ReadToken(prompt string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		token, err := ReadSecret(prompt)
+		return strings.TrimSpace(string(token)), err
+	}
+
+	token, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,331 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// Errors returned by the vault
+var (
+	ErrWrongPassphrase = errors.New("wrong vault passphrase")
+	ErrNoToken         = errors.New("no token stored")
+)
+
+const (
+	// vaultVersion 2 seals each token together with its account name; version
+	// 1 vaults are resealed the first time they are opened
+	vaultVersion = 2
+	// verifierText is sealed with the vault key so a wrong passphrase is
+	// caught before it is used to add tokens nobody can read back
+	verifierText = "ghmm vault"
+	nonceSize    = 24
+)
+
+// kdfParams are the scrypt settings a vault's key was derived with
+type kdfParams struct {
+	Salt string `yaml:"salt"` // base64
+	N    int    `yaml:"cost"`
+	R    int    `yaml:"block_size"`
+	P    int    `yaml:"parallelism"`
+}
+
+// defaultKDF returns scrypt settings with a fresh salt; N=2^15 takes about
+// 100ms and 32MB, the cost recommended for interactive logins
+func defaultKDF() (kdfParams, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return kdfParams{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return kdfParams{Salt: base64.StdEncoding.EncodeToString(salt), N: 1 << 15, R: 8, P: 1}, nil
+}
+
+// vaultFile is the on-disk layout. Account names are stored in the clear so
+// the vault can be listed without the passphrase; each token is sealed
+// separately with NaCl secretbox (XSalsa20-Poly1305) under its own nonce,
+// together with the account name so entries can't be swapped between
+// accounts. Sealed values are base64 of the nonce followed by the box.
+type vaultFile struct {
+	Version  int               `yaml:"version"`
+	KDF      kdfParams         `yaml:"kdf"`
+	Verifier string            `yaml:"verifier"`
+	Tokens   map[string]string `yaml:"tokens,omitempty"`
+}
+
+// Vault is an unlocked token vault
+type Vault struct {
+	path string
+	file vaultFile
+	key  [32]byte
+}
+
+// Exists reports whether a vault has been created at path
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readVaultFile loads the vault at path without unlocking it
+func readVaultFile(path string) (vaultFile, error) {
+	var file vaultFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("failed to read vault: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("failed to parse vault: %w", err)
+	}
+	if file.Version != 1 && file.Version != vaultVersion {
+		return file, fmt.Errorf("failed to read vault: unsupported version %d", file.Version)
+	}
+	return file, nil
+}
+
+// StoredAccounts lists the accounts with a token in the vault at path, which
+// doesn't need the passphrase. A missing vault has none.
+func StoredAccounts(path string) ([]string, error) {
+	if !Exists(path) {
+		return nil, nil
+	}
+	file, err := readVaultFile(path)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]string, 0, len(file.Tokens))
+	for account := range file.Tokens {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts, nil
+}
+
+// RemoveEntry deletes an account's token without the passphrase, so an
+// account can be removed without unlocking the vault. This grants nothing
+// new: anyone who can write the vault file can already delete from it.
+// Nothing happens when there is no vault or no token for the account.
+func RemoveEntry(path, account string) error {
+	if !Exists(path) {
+		return nil
+	}
+	file, err := readVaultFile(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Tokens[account]; !ok {
+		return nil
+	}
+	delete(file.Tokens, account)
+	return writeVaultFile(path, file)
+}
+
+// Open unlocks the vault at path with passphrase, or starts a new one when
+// none exists; a new vault is only written once a token is set
+func Open(path string, passphrase []byte) (*Vault, error) {
+	v := &Vault{path: path}
+
+	if !Exists(path) {
+		kdf, err := defaultKDF()
+		if err != nil {
+			return nil, err
+		}
+		v.file = vaultFile{Version: vaultVersion, KDF: kdf}
+		if err := v.deriveKey(passphrase); err != nil {
+			return nil, err
+		}
+		v.file.Verifier, err = v.seal([]byte(verifierText))
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	file, err := readVaultFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v.file = file
+	if err := v.deriveKey(passphrase); err != nil {
+		return nil, err
+	}
+	if plain, ok := v.open(file.Verifier); !ok || string(plain) != verifierText {
+		return nil, ErrWrongPassphrase
+	}
+	if file.Version == 1 {
+		if err := v.upgrade(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// upgrade reseals a version 1 vault's tokens, which were sealed without
+// their account names
+func (v *Vault) upgrade() error {
+	for account, sealed := range v.file.Tokens {
+		token, ok := v.open(sealed)
+		if !ok {
+			return fmt.Errorf("failed to upgrade vault: entry for '%s' is corrupt", account)
+		}
+		resealed, err := v.sealToken(account, string(token))
+		if err != nil {
+			return err
+		}
+		v.file.Tokens[account] = resealed
+	}
+	v.file.Version = vaultVersion
+	return v.save()
+}
+
+// deriveKey turns the passphrase into the vault key with the vault's scrypt settings
+func (v *Vault) deriveKey(passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return fmt.Errorf("vault passphrase cannot be empty")
+	}
+	kdf := v.file.KDF
+	salt, err := base64.StdEncoding.DecodeString(kdf.Salt)
+	if err != nil {
+		return fmt.Errorf("failed to read vault: bad salt: %w", err)
+	}
+	key, err := scrypt.Key(passphrase, salt, kdf.N, kdf.R, kdf.P, len(v.key))
+	if err != nil {
+		return fmt.Errorf("failed to derive vault key: %w", err)
+	}
+	copy(v.key[:], key)
+	return nil
+}
+
+// seal encrypts plain under a fresh random nonce, which is prepended to the result
+func (v *Vault) seal(plain []byte) (string, error) {
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(secretbox.Seal(nonce[:], plain, &nonce, &v.key)), nil
+}
+
+// open decrypts a value produced by seal
+func (v *Vault) open(encoded string) ([]byte, bool) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < nonceSize {
+		return nil, false
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed[:nonceSize])
+	return secretbox.Open(nil, sealed[nonceSize:], &nonce, &v.key)
+}
+
+// sealToken seals token together with the account it belongs to
+func (v *Vault) sealToken(account, token string) (string, error) {
+	return v.seal([]byte(account + "\x00" + token))
+}
+
+// openToken opens an entry sealed by sealToken, checking it was sealed for account
+func (v *Vault) openToken(account, sealed string) (string, error) {
+	plain, ok := v.open(sealed)
+	if !ok {
+		return "", fmt.Errorf("failed to decrypt token for '%s': vault entry is corrupt", account)
+	}
+	owner, token, ok := strings.Cut(string(plain), "\x00")
+	if !ok || owner != account {
+		return "", fmt.Errorf("failed to decrypt token for '%s': vault entry belongs to another account", account)
+	}
+	return token, nil
+}
+
+// Get returns the account's token
+func (v *Vault) Get(account string) (string, error) {
+	sealed, ok := v.file.Tokens[account]
+	if !ok {
+		return "", fmt.Errorf("%w for '%s'", ErrNoToken, account)
+	}
+	return v.openToken(account, sealed)
+}
+
+// Set stores the account's token and saves the vault
+func (v *Vault) Set(account, token string) error {
+	if token == "" {
+		return fmt.Errorf("token cannot be empty")
+	}
+	sealed, err := v.sealToken(account, token)
+	if err != nil {
+		return err
+	}
+	if v.file.Tokens == nil {
+		v.file.Tokens = map[string]string{}
+	}
+	v.file.Tokens[account] = sealed
+	return v.save()
+}
+
+// Rename moves the account's token to newName and saves the vault. The token
+// is resealed, since each entry is bound to its account's name.
+func (v *Vault) Rename(account, newName string) error {
+	sealed, ok := v.file.Tokens[account]
+	if !ok {
+		return fmt.Errorf("%w for '%s'", ErrNoToken, account)
+	}
+	token, err := v.openToken(account, sealed)
+	if err != nil {
+		return err
+	}
+	resealed, err := v.sealToken(newName, token)
+	if err != nil {
+		return err
+	}
+	delete(v.file.Tokens, account)
+	v.file.Tokens[newName] = resealed
+	return v.save()
+}
+
+// Remove deletes the account's token and saves the vault
+func (v *Vault) Remove(account string) error {
+	if _, ok := v.file.Tokens[account]; !ok {
+		return fmt.Errorf("%w for '%s'", ErrNoToken, account)
+	}
+	delete(v.file.Tokens, account)
+	return v.save()
+}
+
+// save writes the vault to its file
+func (v *Vault) save() error {
+	return writeVaultFile(v.path, v.file)
+}
+
+// writeVaultFile writes the vault readable only by the user, replacing the old file in one step
+func writeVaultFile(path string, file vaultFile) error {
+	data, err := yaml.Marshal(&file)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tokens-*.vault")
+	if err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	// CreateTemp already uses 0600; keep it explicit since this file holds secrets
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// newVault creates a vault at a temporary path holding the given tokens
+func newVault(t *testing.T, tokens map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.vault")
+	v, err := Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for account, token := range tokens {
+		if err := v.Set(account, token); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	return path
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	path := newVault(t, map[string]string{"work": "gho_work", "personal": "gho_personal"})
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("vault mode = %04o, want 0600", perm)
+	}
+
+	if _, err := Open(path, []byte("wrong")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Open() with the wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+
+	v, err := Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for account, want := range map[string]string{"work": "gho_work", "personal": "gho_personal"} {
+		if got, err := v.Get(account); err != nil || got != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", account, got, err, want)
+		}
+	}
+	if _, err := v.Get("missing"); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Get(missing) error = %v, want ErrNoToken", err)
+	}
+}
+
+func TestVaultSwappedEntries(t *testing.T) {
+	path := newVault(t, map[string]string{"work": "gho_work", "personal": "gho_personal"})
+
+	// Someone with write access swaps the two ciphertexts
+	file, err := readVaultFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Tokens["work"], file.Tokens["personal"] = file.Tokens["personal"], file.Tokens["work"]
+	if err := writeVaultFile(path, file); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if token, err := v.Get("work"); err == nil {
+		t.Errorf("Get(work) = %q after swapping entries, want an error", token)
+	}
+}
+
+func TestVaultRename(t *testing.T) {
+	path := newVault(t, map[string]string{"work": "gho_work"})
+
+	v, err := Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := v.Rename("work", "job"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	v, err = Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got, err := v.Get("job"); err != nil || got != "gho_work" {
+		t.Errorf("Get(job) = %q, %v, want gho_work", got, err)
+	}
+	if _, err := v.Get("work"); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Get(work) error = %v, want ErrNoToken", err)
+	}
+}
+
+func TestRemoveEntry(t *testing.T) {
+	path := newVault(t, map[string]string{"work": "gho_work", "personal": "gho_personal"})
+
+	if err := RemoveEntry(path, "work"); err != nil {
+		t.Fatalf("RemoveEntry() error = %v", err)
+	}
+	if err := RemoveEntry(path, "work"); err != nil {
+		t.Errorf("RemoveEntry() of a missing entry error = %v, want nil", err)
+	}
+	if err := RemoveEntry(filepath.Join(t.TempDir(), "none.vault"), "work"); err != nil {
+		t.Errorf("RemoveEntry() without a vault error = %v, want nil", err)
+	}
+
+	stored, err := StoredAccounts(path)
+	if err != nil || len(stored) != 1 || stored[0] != "personal" {
+		t.Errorf("StoredAccounts() = %v, %v, want [personal]", stored, err)
+	}
+}
+
+func TestVaultUpgrade(t *testing.T) {
+	path := newVault(t, nil)
+
+	// Write a version 1 vault, whose tokens were sealed without their names
+	v, err := Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	sealed, err := v.seal([]byte("gho_work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.file.Version = 1
+	v.file.Tokens = map[string]string{"work": sealed}
+	if err := v.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = Open(path, []byte("secret"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got, err := v.Get("work"); err != nil || got != "gho_work" {
+		t.Errorf("Get(work) = %q, %v, want gho_work", got, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file vaultFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != vaultVersion {
+		t.Errorf("version after upgrade = %d, want %d", file.Version, vaultVersion)
+	}
+}
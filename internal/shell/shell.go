@@ -5,9 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/managed"
 )
 
 // ShellType represents the type of shell
@@ -89,24 +91,142 @@ func (m *Manager) GenerateGCloneFunction(accounts []config.Account, defaultAccou
 	}
 }
 
-// generatePOSIXFunction generates gclone for bash/zsh
-func (m *Manager) generatePOSIXFunction(accounts []config.Account, defaultAccount string) string {
-	var caseStatements []string
-	defaultHost := "github.com"
+// matchOrder returns every account directory, most specific first. The first
+// matching case wins, so a nested directory must be tested before its parent.
+func matchOrder(accounts []config.Account) []config.Mapping {
+	ordered := config.DirectoryMappings(accounts)
+	slices.Reverse(ordered)
+	return ordered
+}
 
+// defaultAccountOf returns the default account, or a bare github.com account
+// when there is none
+func defaultAccountOf(accounts []config.Account, defaultAccount string) config.Account {
 	for _, account := range accounts {
-		isDefault := (defaultAccount == account.Name)
-		if isDefault {
-			defaultHost = account.HostAlias
+		if account.Name == defaultAccount {
+			return account
 		}
+	}
+	return config.Account{HostAlias: config.DefaultHost}
+}
+
+// usesSSHCommand reports whether any account picks its key with
+// core.sshCommand, which gclone then has to pass to git clone
+func usesSSHCommand(accounts []config.Account) bool {
+	return slices.ContainsFunc(accounts, config.Account.UsesSSHCommand)
+}
+
+// cloneSSHCommand returns the ssh command gclone passes to git clone for an account, or ""
+func cloneSSHCommand(account config.Account) string {
+	if account.UsesSSHCommand() {
+		return account.SSHCommand()
+	}
+	return ""
+}
+
+// claimantMappings returns the directories of the accounts listing an org, most
+// specific first, so an owner listed by several accounts is settled by directory
+func claimantMappings(accounts, claimants []config.Account) []config.Mapping {
+	var mappings []config.Mapping
+	for _, mapping := range matchOrder(accounts) {
+		for _, claimant := range claimants {
+			if mapping.Account.Name == claimant.Name {
+				mappings = append(mappings, mapping)
+				break
+			}
+		}
+	}
+	return mappings
+}
+
+// accountNames joins the names of accounts for messages
+func accountNames(accounts []config.Account) string {
+	names := make([]string, len(accounts))
+	for i, account := range accounts {
+		names[i] = account.Name
+	}
+	return strings.Join(names, ", ")
+}
 
-		caseStatements = append(caseStatements, fmt.Sprintf(`        *%s*)
-            host="%s"
-            echo "🔑 Cloning with %s account..."
-            ;;`, account.Directory, account.HostAlias, account.Name))
+// posixSelect renders the assignments that make gclone clone with account.
+// ssh_command is only used, and so only set, when some account needs it.
+func posixSelect(account config.Account, withCommand bool, indent string) string {
+	lines := fmt.Sprintf(`%shost="%s"`, indent, account.SSHHost())
+	if withCommand {
+		lines += fmt.Sprintf("\n%sssh_command='%s'", indent, cloneSSHCommand(account))
+	}
+	return lines
+}
+
+// posixDirectoryCases renders case clauses matching $PWD against account directories
+func posixDirectoryCases(mappings []config.Mapping, withCommand bool, indent string) string {
+	var caseStatements []string
+	for _, mapping := range mappings {
+		dir := strings.TrimRight(mapping.Directory, "/")
+		caseStatements = append(caseStatements, fmt.Sprintf(`%[1]s"%[2]s"|"%[2]s"/*)
+%[3]s
+%[1]s    echo "🔑 Cloning with %[4]s account..."
+%[1]s    ;;`, indent, dir, posixSelect(mapping.Account, withCommand, indent+"    "), mapping.Account.Name))
+	}
+	return strings.Join(caseStatements, "\n")
+}
+
+// posixOwnerBlock renders the case statement picking the host by repository
+// owner, or "" when no account lists any orgs
+func posixOwnerBlock(accounts []config.Account, withCommand bool) string {
+	owners := config.OrgOwners(accounts)
+	if len(owners) == 0 {
+		return ""
+	}
+
+	var caseStatements []string
+	for _, org := range config.SortedOrgs(owners) {
+		claimants := owners[org]
+		if len(claimants) == 1 {
+			caseStatements = append(caseStatements, fmt.Sprintf(`        "%s")
+%s
+            echo "🔑 Cloning with %s account (%s is in its orgs)..."
+            ;;`, org, posixSelect(claimants[0], withCommand, "            "), claimants[0].Name, org))
+			continue
+		}
+		caseStatements = append(caseStatements, fmt.Sprintf(`        "%s")
+            echo "⚠️  %s is listed by several accounts (%s); choosing by directory"
+%s
+            case "$PWD" in
+%s
+            esac
+            ;;`, org, org, accountNames(claimants), posixSelect(claimants[0], withCommand, "            "),
+			posixDirectoryCases(claimantMappings(accounts, claimants), withCommand, "                ")))
 	}
 
-	cases := strings.Join(caseStatements, "\n")
+	return fmt.Sprintf(`
+    # Accounts listing the repository owner in their orgs come first
+    local owner
+    owner=$(printf '%%s' "${owner_repo%%%%/*}" | tr '[:upper:]' '[:lower:]')
+    case "$owner" in
+%s
+        *)
+            echo "ℹ️  No account lists $owner in its orgs; choosing by directory"
+            ;;
+    esac
+`, strings.Join(caseStatements, "\n"))
+}
+
+// generatePOSIXFunction generates gclone for bash/zsh
+func (m *Manager) generatePOSIXFunction(accounts []config.Account, defaultAccount string) string {
+	withCommand := usesSSHCommand(accounts)
+	cases := posixDirectoryCases(matchOrder(accounts), withCommand, "            ")
+
+	locals := `    local host=""`
+	clone := `    git clone "git@${host}:${owner_repo}.git"`
+	if withCommand {
+		locals += "\n" + `    local ssh_command=""`
+		clone = `    if [[ -n "$ssh_command" ]]; then
+        git clone -c core.sshCommand="$ssh_command" "git@${host}:${owner_repo}.git"
+    else
+        git clone "git@${host}:${owner_repo}.git"
+    fi`
+	}
 
 	return fmt.Sprintf(`
 # Smart git clone - automatically uses the right GitHub account
@@ -124,7 +244,7 @@ _smart_clone() {
     # Extract owner/repo from various URL formats
     local owner_repo=""
     if [[ $repo_url =~ github\.com[:/]([^/]+/[^/]+)(\.git)?$ ]]; then
-        owner_repo="${BASH_REMATCH[1]%%.git}"
+        owner_repo="${BASH_REMATCH[1]%%%%.git}"
     elif [[ $repo_url =~ ^([^/]+/[^/]+)$ ]]; then
         owner_repo="$repo_url"
     else
@@ -135,35 +255,97 @@ _smart_clone() {
         return 1
     fi
     
-    # Determine which host to use based on current directory
-    local host="%s"
-    case "$PWD" in
 %s
-        *)
-            echo "👤 Cloning with default account..."
-            ;;
-    esac
+%s
+    # Otherwise determine which host to use based on current directory
+    if [[ -z "$host" ]]; then
+%s
+        case "$PWD" in
+%s
+            *)
+                echo "👤 Cloning with default account..."
+                ;;
+        esac
+    fi
     
-    git clone "git@${host}:${owner_repo}.git"
+%s
 }
-`, defaultHost, cases)
+`, locals, posixOwnerBlock(accounts, withCommand),
+		posixSelect(defaultAccountOf(accounts, defaultAccount), withCommand, "        "), cases, clone)
 }
 
-// generateFishFunction generates gclone for fish shell
-func (m *Manager) generateFishFunction(accounts []config.Account, defaultAccount string) string {
+// fishSelect renders the assignments that make gclone clone with account
+func fishSelect(account config.Account, withCommand bool, indent string) string {
+	lines := fmt.Sprintf(`%sset host "%s"`, indent, account.SSHHost())
+	if withCommand {
+		lines += fmt.Sprintf("\n%sset ssh_command '%s'", indent, cloneSSHCommand(account))
+	}
+	return lines
+}
+
+// fishDirectoryCases renders switch cases matching $PWD against account directories
+func fishDirectoryCases(mappings []config.Mapping, withCommand bool, indent string) string {
 	var caseStatements []string
-	defaultHost := "github.com"
+	for _, mapping := range mappings {
+		dir := strings.TrimRight(mapping.Directory, "/")
+		caseStatements = append(caseStatements, fmt.Sprintf(`%[1]scase '%[2]s' '%[2]s/*'
+%[3]s
+%[1]s    echo "🔑 Cloning with %[4]s account..."
+`, indent, dir, fishSelect(mapping.Account, withCommand, indent+"    "), mapping.Account.Name))
+	}
+	return strings.Join(caseStatements, "")
+}
 
-	for _, account := range accounts {
-		isDefault := (defaultAccount == account.Name)
-		if isDefault {
-			defaultHost = account.HostAlias
+// fishOwnerBlock renders the switch picking the host by repository owner, or
+// "" when no account lists any orgs
+func fishOwnerBlock(accounts []config.Account, withCommand bool) string {
+	owners := config.OrgOwners(accounts)
+	if len(owners) == 0 {
+		return ""
+	}
+
+	var caseStatements []string
+	for _, org := range config.SortedOrgs(owners) {
+		claimants := owners[org]
+		if len(claimants) == 1 {
+			caseStatements = append(caseStatements, fmt.Sprintf(`        case '%s'
+%s
+            echo "🔑 Cloning with %s account (%s is in its orgs)..."
+`, org, fishSelect(claimants[0], withCommand, "            "), claimants[0].Name, org))
+			continue
 		}
+		caseStatements = append(caseStatements, fmt.Sprintf(`        case '%s'
+            echo "⚠️  %s is listed by several accounts (%s); choosing by directory"
+%s
+            switch $PWD
+%s            end
+`, org, org, accountNames(claimants), fishSelect(claimants[0], withCommand, "            "),
+			fishDirectoryCases(claimantMappings(accounts, claimants), withCommand, "                ")))
+	}
 
-		caseStatements = append(caseStatements, fmt.Sprintf(`        case '*%s*'
-            set host "%s"
-            echo "🔑 Cloning with %s account..."
-`, account.Directory, account.HostAlias, account.Name))
+	return fmt.Sprintf(`
+    # Accounts listing the repository owner in their orgs come first
+    set owner (string lower (string split -m1 / $owner_repo)[1])
+    switch $owner
+%s        case '*'
+            echo "ℹ️  No account lists $owner in its orgs; choosing by directory"
+    end
+`, strings.Join(caseStatements, ""))
+}
+
+// generateFishFunction generates gclone for fish shell
+func (m *Manager) generateFishFunction(accounts []config.Account, defaultAccount string) string {
+	withCommand := usesSSHCommand(accounts)
+
+	locals := `    set host ""`
+	clone := `    git clone "git@$host:$owner_repo.git"`
+	if withCommand {
+		locals += "\n" + `    set ssh_command ""`
+		clone = `    if test -n "$ssh_command"
+        git clone -c core.sshCommand="$ssh_command" "git@$host:$owner_repo.git"
+    else
+        git clone "git@$host:$owner_repo.git"
+    end`
 	}
 
 	return fmt.Sprintf(`
@@ -191,35 +373,108 @@ function gclone
         return 1
     end
     
-    # Determine which host to use based on current directory
-    set host "%s"
-    switch $PWD
-%s        case '*'
-            echo "👤 Cloning with default account..."
+%s
+%s
+    # Otherwise determine which host to use based on current directory
+    if test -z "$host"
+%s
+        switch $PWD
+%s            case '*'
+                echo "👤 Cloning with default account..."
+        end
     end
     
-    git clone "git@$host:$owner_repo.git"
+%s
 end
-`, defaultHost, strings.Join(caseStatements, ""))
+`, locals, fishOwnerBlock(accounts, withCommand),
+		fishSelect(defaultAccountOf(accounts, defaultAccount), withCommand, "        "),
+		fishDirectoryCases(matchOrder(accounts), withCommand, "            "), clone)
 }
 
-// generatePowerShellFunction generates gclone for PowerShell
-func (m *Manager) generatePowerShellFunction(accounts []config.Account, defaultAccount string) string {
+// powerShellSelect renders the statements that make gclone clone with account
+func powerShellSelect(account config.Account, withCommand bool) string {
+	statements := fmt.Sprintf(`$sshHost = "%s"`, account.SSHHost())
+	if withCommand {
+		statements += fmt.Sprintf(`; $sshCommand = '%s'`, cloneSSHCommand(account))
+	}
+	return statements
+}
+
+// powerShellDirectoryCases renders wildcard switch clauses matching the current
+// directory against account directories
+func powerShellDirectoryCases(mappings []config.Mapping, withCommand bool, indent string) string {
 	var switchCases []string
-	defaultHost := "github.com"
+	for _, mapping := range mappings {
+		// PowerShell runs every matching case, so break after the first
+		dir := strings.TrimRight(mapping.Directory, `/\`)
+		separator := "/"
+		if strings.Contains(dir, `\`) {
+			separator = `\`
+		}
+		selectAccount := powerShellSelect(mapping.Account, withCommand)
+		switchCases = append(switchCases,
+			fmt.Sprintf(`%s"%s" { %s; break }`, indent, dir, selectAccount),
+			fmt.Sprintf(`%s"%s%s*" { %s; break }`, indent, dir, separator, selectAccount))
+	}
+	return strings.Join(switchCases, "\n")
+}
 
-	for _, account := range accounts {
-		isDefault := (defaultAccount == account.Name)
-		if isDefault {
-			defaultHost = account.HostAlias
+// powerShellOwnerBlock renders the switch picking the host by repository
+// owner, or "" when no account lists any orgs
+func powerShellOwnerBlock(accounts []config.Account, withCommand bool) string {
+	owners := config.OrgOwners(accounts)
+	if len(owners) == 0 {
+		return ""
+	}
+
+	var switchCases []string
+	for _, org := range config.SortedOrgs(owners) {
+		claimants := owners[org]
+		if len(claimants) == 1 {
+			switchCases = append(switchCases, fmt.Sprintf(`        "%s" {
+            %s
+            Write-Host "Cloning with %s account ($owner is in its orgs)..."
+            break
+        }`, org, powerShellSelect(claimants[0], withCommand), claimants[0].Name))
+			continue
 		}
+		switchCases = append(switchCases, fmt.Sprintf(`        "%s" {
+            Write-Host "$owner is listed by several accounts (%s); choosing by directory"
+            %s
+            switch -Wildcard ($currentDir) {
+%s
+            }
+            break
+        }`, org, accountNames(claimants), powerShellSelect(claimants[0], withCommand),
+			powerShellDirectoryCases(claimantMappings(accounts, claimants), withCommand, "                ")))
+	}
+
+	return fmt.Sprintf(`
+    # Accounts listing the repository owner in their orgs come first
+    $owner = ($ownerRepo -split '/')[0].ToLower()
+    switch ($owner) {
+%s
+        default { Write-Host "No account lists $owner in its orgs; choosing by directory" }
+    }
+`, strings.Join(switchCases, "\n"))
+}
 
-		// PowerShell switch case with wildcard matching
-		switchCases = append(switchCases, fmt.Sprintf(`        "*%s*" { $host = "%s" }`,
-			strings.ReplaceAll(account.Directory, "\\", "\\\\"),
-			account.HostAlias))
+// generatePowerShellFunction generates gclone for PowerShell
+func (m *Manager) generatePowerShellFunction(accounts []config.Account, defaultAccount string) string {
+	withCommand := usesSSHCommand(accounts)
+
+	locals := `    $sshHost = ""`
+	clone := `    git clone $gitUrl`
+	if withCommand {
+		locals += "\n" + `    $sshCommand = ""`
+		clone = `    if ($sshCommand) {
+        git clone -c "core.sshCommand=$sshCommand" $gitUrl
+    } else {
+        git clone $gitUrl
+    }`
 	}
 
+	// $host is a read-only automatic variable in PowerShell, hence $sshHost
 	return fmt.Sprintf(`
 # Smart git clone - automatically uses the right GitHub account
 # Generated by GitHub Multi-Account Manager (ghmm)
@@ -243,20 +498,41 @@ function gclone {
         return
     }
     
-    # Determine which host to use based on current directory
-    $host = "%s"
+%s
     $currentDir = (Get-Location).Path
-    switch -Wildcard ($currentDir) {
 %s
-        default { $host = "%s" }
+    # Otherwise determine which host to use based on current directory
+    if (-not $sshHost) {
+        switch -Wildcard ($currentDir) {
+%s
+            default { %s }
+        }
     }
     
     # Clone with the appropriate host
-    $gitUrl = "git@$($host):$ownerRepo.git"
-    Write-Host "Cloning from $host..."
-    git clone $gitUrl
+    $gitUrl = "git@$($sshHost):$ownerRepo.git"
+    Write-Host "Cloning from $sshHost..."
+%s
+}
+`, locals, powerShellOwnerBlock(accounts, withCommand),
+		powerShellDirectoryCases(matchOrder(accounts), withCommand, "            "),
+		powerShellSelect(defaultAccountOf(accounts, defaultAccount), withCommand), clone)
 }
-`, defaultHost, strings.Join(switchCases, "\n"), defaultHost)
+
+// Markers delimiting the section of the shell config owned by ghmm
+const (
+	shellStartMarker = "# BEGIN GHMM SMART CLONE\n"
+	shellEndMarker   = "# END GHMM SMART CLONE\n"
+)
+
+// CurrentShellSection returns the ghmm managed section currently in the shell config
+func (m *Manager) CurrentShellSection() string {
+	return managed.Section(m.ConfigFile, shellStartMarker, shellEndMarker)
+}
+
+// GenerateShellSection renders the ghmm managed section for the shell config
+func (m *Manager) GenerateShellSection(accounts []config.Account, defaultAccount string) string {
+	return shellStartMarker + m.GenerateGCloneFunction(accounts, defaultAccount) + shellEndMarker
 }
 
 // UpdateShellConfig updates shell config with gclone function
@@ -269,40 +545,12 @@ func (m *Manager) UpdateShellConfig(accounts []config.Account, defaultAccount st
 		}
 	}
 
-	const (
-		startMarker = "# BEGIN GHMM SMART CLONE\n"
-		endMarker   = "# END GHMM SMART CLONE\n"
-	)
-
+	// Read existing config, replacing any old ghmm section
 	existingContent := ""
-
-	// Read existing config
 	if data, err := os.ReadFile(m.ConfigFile); err == nil {
-		content := string(data)
-
-		// Remove old ghmm section if it exists
-		if strings.Contains(content, startMarker) {
-			parts := strings.Split(content, startMarker)
-			before := parts[0]
-
-			afterParts := strings.Split(content, endMarker)
-			after := ""
-			if len(afterParts) > 1 {
-				after = afterParts[1]
-			}
-
-			existingContent = before + after
-		} else {
-			existingContent = content
-		}
+		existingContent = string(data)
 	}
-
-	// Generate new function
-	gcloneFunction := m.GenerateGCloneFunction(accounts, defaultAccount)
-	newSection := startMarker + gcloneFunction + endMarker
-
-	// Write updated config
-	finalContent := strings.TrimRight(existingContent, "\n") + "\n\n" + newSection
+	finalContent := managed.Replace(existingContent, shellStartMarker, shellEndMarker, m.GenerateShellSection(accounts, defaultAccount))
 
 	if err := os.WriteFile(m.ConfigFile, []byte(finalContent), 0644); err != nil {
 		return fmt.Errorf("failed to write shell config: %w", err)
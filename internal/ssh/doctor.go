@@ -0,0 +1,144 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/ssh/sshconfig"
+)
+
+// Severity ranks how serious a Problem is
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+// Problem is an issue found while checking an account's SSH setup
+type Problem struct {
+	Account  string
+	Severity Severity
+	Message  string
+}
+
+// LoadConfig parses ~/.ssh/config, following Include directives
+func (m *Manager) LoadConfig() (*sshconfig.Config, error) {
+	cfg, err := sshconfig.Load(m.configFile)
+	if os.IsNotExist(err) {
+		return &sshconfig.Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH config: %w", err)
+	}
+	return cfg, nil
+}
+
+// CheckAccounts evaluates ~/.ssh/config the way ssh would for each account's
+// host alias and reports anything that stops it from using the account's key,
+// including earlier Host or Match blocks that override the ghmm section
+func (m *Manager) CheckAccounts(accounts []config.Account) ([]Problem, error) {
+	cfg, err := m.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	defined := map[string]bool{}
+	for _, alias := range cfg.Aliases() {
+		defined[alias] = true
+	}
+
+	var problems []Problem
+	keyOwners := map[string]string{}
+
+	for _, account := range accounts {
+		report := func(severity Severity, format string, args ...any) {
+			problems = append(problems, Problem{
+				Account:  account.Name,
+				Severity: severity,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		keyPath := expandPath(account.SSHKeyPath)
+		if _, err := os.Stat(keyPath); err != nil {
+			report(SeverityError, "SSH key %s does not exist", account.SSHKeyPath)
+		} else if err := CheckKeyPermissions(account.SSHKeyPath); err != nil {
+			report(SeverityError, "%v", err)
+		}
+
+		if owner, ok := keyOwners[keyPath]; ok {
+			report(SeverityError, "shares SSH key %s with account '%s'; GitHub maps each key to one user", account.SSHKeyPath, owner)
+		} else {
+			keyOwners[keyPath] = account.Name
+		}
+
+		// core.sshCommand passes the key on the command line, ahead of anything
+		// in ~/.ssh/config, so there is no host alias to check
+		if account.UsesSSHCommand() {
+			continue
+		}
+
+		if !defined[account.HostAlias] {
+			report(SeverityError, "host alias %s is not defined in %s; apply configs first", account.HostAlias, m.configFile)
+			continue
+		}
+
+		resolved := cfg.Resolve(account.HostAlias)
+
+		if resolved.HostName != account.GitHubHost() {
+			report(SeverityError, "%s resolves to HostName %s%s", account.HostAlias, resolved.HostName, origin(resolved, "hostname"))
+		}
+
+		if resolved.User != "git" {
+			report(SeverityError, "%s connects as user %s%s, GitHub expects git", account.HostAlias, resolved.User, origin(resolved, "user"))
+		}
+
+		keyIndex := -1
+		for i, file := range resolved.IdentityFiles {
+			if filepath.Clean(file) == filepath.Clean(keyPath) {
+				keyIndex = i
+				break
+			}
+		}
+
+		switch {
+		case keyIndex == -1:
+			report(SeverityError, "ssh will not offer %s for %s", account.SSHKeyPath, account.HostAlias)
+		case keyIndex > 0:
+			// GitHub accepts the first key it recognizes, which may belong to another account
+			report(SeverityError, "ssh offers %s (%s) before %s; it may authenticate as a different account",
+				resolved.IdentityFiles[0], resolved.Origins["identityfile"][0], account.SSHKeyPath)
+		}
+
+		if !resolved.IdentitiesOnly {
+			report(SeverityWarning, "IdentitiesOnly is off%s, so keys loaded in ssh-agent may be offered first", origin(resolved, "identitiesonly"))
+		}
+
+		if resolved.IdentityAgent != "" && !strings.EqualFold(resolved.IdentityAgent, "none") {
+			report(SeverityWarning, "IdentityAgent %s%s may offer keys for other accounts", resolved.IdentityAgent, origin(resolved, "identityagent"))
+		}
+	}
+
+	return problems, nil
+}
+
+// origin formats where an option was set, for use in problem messages
+func origin(resolved sshconfig.HostConfig, keyword string) string {
+	if origins := resolved.Origins[keyword]; len(origins) > 0 {
+		return fmt.Sprintf(" (set at %s)", origins[0])
+	}
+	return ""
+}
+
+// expandPath expands a leading ~/ to the home directory
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
@@ -0,0 +1,136 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Key types GenerateKey accepts
+const (
+	KeyTypeEd25519   = "ed25519"
+	KeyTypeEd25519SK = "ed25519-sk" // FIDO2 security key
+	KeyTypeECDSASK   = "ecdsa-sk"   // FIDO2 security key without ed25519 support
+)
+
+// KeyOptions selects the kind of key GenerateKey creates
+type KeyOptions struct {
+	Type string // ed25519 when empty
+	// Resident stores the key on the security key itself, so it can be
+	// recovered on another machine with ssh-keygen -K
+	Resident bool
+}
+
+// KeyChoices are the kinds of key offered when generating one
+var KeyChoices = []KeyOptions{
+	{Type: KeyTypeEd25519},
+	{Type: KeyTypeEd25519SK},
+	{Type: KeyTypeEd25519SK, Resident: true},
+	{Type: KeyTypeECDSASK},
+	{Type: KeyTypeECDSASK, Resident: true},
+}
+
+// ParseKeyOptions validates a key type and resident flag given on the command line
+func ParseKeyOptions(keyType string, resident bool) (KeyOptions, error) {
+	opts := KeyOptions{Type: keyType, Resident: resident}
+	switch opts.keyType() {
+	case KeyTypeEd25519:
+		if resident {
+			return KeyOptions{}, fmt.Errorf("only security keys (ed25519-sk, ecdsa-sk) can be resident")
+		}
+	case KeyTypeEd25519SK, KeyTypeECDSASK:
+	default:
+		return KeyOptions{}, fmt.Errorf("unknown key type %q: must be ed25519, ed25519-sk or ecdsa-sk", keyType)
+	}
+	return opts, nil
+}
+
+// keyType returns the ssh-keygen -t value, ed25519 when unset
+func (o KeyOptions) keyType() string {
+	if o.Type == "" {
+		return KeyTypeEd25519
+	}
+	return o.Type
+}
+
+// SecurityKey reports whether the key lives on a FIDO2 security key
+func (o KeyOptions) SecurityKey() bool {
+	return strings.HasSuffix(o.keyType(), "-sk")
+}
+
+// String describes the kind of key for display
+func (o KeyOptions) String() string {
+	switch {
+	case o.Resident:
+		return o.keyType() + " (security key, resident)"
+	case o.SecurityKey():
+		return o.keyType() + " (security key)"
+	}
+	return o.keyType()
+}
+
+// KeygenCommand returns the ssh-keygen command that creates a key at keyPath.
+// A security key generates the key itself and asks for a touch (and its PIN
+// when resident), so the command must run attached to a terminal.
+func (m *Manager) KeygenCommand(keyPath, email string, opts KeyOptions) (*exec.Cmd, error) {
+	keyPath = expandPath(keyPath)
+
+	// Check if key already exists
+	if _, err := os.Stat(keyPath); err == nil {
+		return nil, fmt.Errorf("key already exists at %s", keyPath)
+	}
+
+	args := []string{
+		"-t", opts.keyType(),
+		"-C", email,
+		"-f", keyPath,
+		"-N", "", // No passphrase
+	}
+	if opts.Resident {
+		args = append(args, "-O", "resident")
+	}
+	return exec.Command("ssh-keygen", args...), nil
+}
+
+// FinishKey sets the permissions ssh requires on a newly generated private key.
+// A security key's private key file is only a stub holding the key handle,
+// but ssh refuses it all the same when others can read it.
+func (m *Manager) FinishKey(keyPath string) error {
+	if err := os.Chmod(expandPath(keyPath), 0600); err != nil {
+		return fmt.Errorf("failed to set key permissions: %w", err)
+	}
+	return nil
+}
+
+// PublicKeyType returns the algorithm of the key at keyPath, e.g.
+// sk-ssh-ed25519@openssh.com, or "" when its public key can't be read
+func PublicKeyType(keyPath string) string {
+	data, err := os.ReadFile(expandPath(keyPath) + ".pub")
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// IsSecurityKey reports whether the key at keyPath is a FIDO2 security key,
+// which needs a touch every time git connects or signs
+func IsSecurityKey(keyPath string) bool {
+	return strings.HasPrefix(PublicKeyType(keyPath), "sk-")
+}
+
+// CheckKeyPermissions reports a private key that others can access, which ssh refuses to use
+func CheckKeyPermissions(keyPath string) error {
+	info, err := os.Stat(expandPath(keyPath))
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("%s is accessible by others (mode %04o); ssh will refuse it, run chmod 600 %s", keyPath, perm, keyPath)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeKeygen puts an ssh-keygen on PATH that writes a key pair whose public
+// key has the algorithm of the requested type, the way a security key's does
+func fakeKeygen(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh-keygen is a shell script")
+	}
+
+	bin := t.TempDir()
+	script := `#!/bin/sh
+t=ed25519; f=
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-t) t=$2; shift ;;
+	-f) f=$2; shift ;;
+	-C|-N|-O) shift ;;
+	esac
+	shift
+done
+case $t in
+ed25519-sk) algo=sk-ssh-ed25519@openssh.com ;;
+ecdsa-sk) algo=sk-ecdsa-sha2-nistp256@openssh.com ;;
+*) algo=ssh-ed25519 ;;
+esac
+echo "private $algo" > "$f"
+chmod 644 "$f"
+echo "$algo AAAAtest test@example.com" > "$f.pub"
+`
+	if err := os.WriteFile(filepath.Join(bin, "ssh-keygen"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestKeygenCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     KeyOptions
+		wantType string
+	}{
+		{"default", KeyOptions{}, "ed25519"},
+		{"security key", KeyOptions{Type: KeyTypeEd25519SK}, "ed25519-sk"},
+		{"resident", KeyOptions{Type: KeyTypeECDSASK, Resident: true}, "ecdsa-sk"},
+	}
+
+	m := &Manager{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyPath := filepath.Join(t.TempDir(), "id_test")
+			cmd, err := m.KeygenCommand(keyPath, "test@example.com", tt.opts)
+			if err != nil {
+				t.Fatalf("KeygenCommand() error = %v", err)
+			}
+
+			args := strings.Join(cmd.Args[1:], " ")
+			if !strings.Contains(args, "-t "+tt.wantType+" ") {
+				t.Errorf("args = %q, want key type %s", args, tt.wantType)
+			}
+			if !strings.Contains(args, "-f "+keyPath) || !strings.Contains(args, "-C test@example.com") {
+				t.Errorf("args = %q, want the key path and comment", args)
+			}
+			if got := strings.Contains(args, "-O resident"); got != tt.opts.Resident {
+				t.Errorf("args = %q, resident option = %v, want %v", args, got, tt.opts.Resident)
+			}
+		})
+	}
+}
+
+func TestKeygenCommandExistingKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "id_test")
+	if err := os.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&Manager{}).KeygenCommand(keyPath, "test@example.com", KeyOptions{}); err == nil {
+		t.Error("KeygenCommand() error = nil, want an error for an existing key")
+	}
+}
+
+func TestFinishKey(t *testing.T) {
+	fakeKeygen(t)
+	m := &Manager{}
+	keyPath := filepath.Join(t.TempDir(), "id_test")
+
+	cmd, err := m.KeygenCommand(keyPath, "test@example.com", KeyOptions{Type: KeyTypeEd25519SK})
+	if err != nil {
+		t.Fatalf("KeygenCommand() error = %v", err)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %v: %s", err, output)
+	}
+	if err := CheckKeyPermissions(keyPath); err == nil {
+		t.Fatal("CheckKeyPermissions() error = nil before FinishKey, want the fake's 0644 to be reported")
+	}
+
+	if err := m.FinishKey(keyPath); err != nil {
+		t.Fatalf("FinishKey() error = %v", err)
+	}
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("mode = %04o, want 0600", perm)
+	}
+	if err := CheckKeyPermissions(keyPath); err != nil {
+		t.Errorf("CheckKeyPermissions() error = %v", err)
+	}
+}
+
+func TestFinishKeyMissing(t *testing.T) {
+	if err := (&Manager{}).FinishKey(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("FinishKey() error = nil, want an error for a missing key")
+	}
+}
+
+func TestIsSecurityKey(t *testing.T) {
+	fakeKeygen(t)
+	m := &Manager{}
+
+	tests := []struct {
+		opts KeyOptions
+		want bool
+	}{
+		{KeyOptions{Type: KeyTypeEd25519}, false},
+		{KeyOptions{Type: KeyTypeEd25519SK}, true},
+		{KeyOptions{Type: KeyTypeECDSASK, Resident: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.opts.String(), func(t *testing.T) {
+			keyPath := filepath.Join(t.TempDir(), "id_test")
+			cmd, err := m.KeygenCommand(keyPath, "test@example.com", tt.opts)
+			if err != nil {
+				t.Fatalf("KeygenCommand() error = %v", err)
+			}
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("ssh-keygen failed: %v: %s", err, output)
+			}
+
+			if got := IsSecurityKey(keyPath); got != tt.want {
+				t.Errorf("IsSecurityKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if IsSecurityKey(filepath.Join(t.TempDir(), "missing")) {
+		t.Error("IsSecurityKey() = true for a missing key, want false")
+	}
+}
+
+func TestGetPublicKeyFallbackError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh-keygen is a shell script")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho 'invalid format' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "ssh-keygen"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	keyPath := filepath.Join(t.TempDir(), "id_test")
+	if err := os.WriteFile(keyPath, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := (&Manager{}).GetPublicKey(keyPath)
+	if err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("GetPublicKey() error = %v, want ssh-keygen's message", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/managed"
 )
 
 // Manager handles SSH key operations
@@ -37,65 +38,57 @@ func New() (*Manager, error) {
 	}, nil
 }
 
-// GenerateKey generates a new SSH key
-func (m *Manager) GenerateKey(keyPath, email, keyType string) error {
-	if keyType == "" {
-		keyType = "ed25519"
-	}
-
-	// Expand home directory
-	if strings.HasPrefix(keyPath, "~/") {
-		home, _ := os.UserHomeDir()
-		keyPath = filepath.Join(home, keyPath[2:])
-	}
-
-	// Check if key already exists
-	if _, err := os.Stat(keyPath); err == nil {
-		return fmt.Errorf("key already exists at %s", keyPath)
-	}
-
-	// Generate the key
-	cmd := exec.Command("ssh-keygen",
-		"-t", keyType,
-		"-C", email,
-		"-f", keyPath,
-		"-N", "", // No passphrase
-	)
-
-	output, err := cmd.CombinedOutput()
+// GenerateKey generates a new SSH key. Security keys prompt for a touch on
+// the terminal; use KeygenCommand to run ssh-keygen under a TUI instead.
+func (m *Manager) GenerateKey(keyPath, email string, opts KeyOptions) error {
+	cmd, err := m.KeygenCommand(keyPath, email, opts)
 	if err != nil {
-		return fmt.Errorf("failed to generate key: %s", string(output))
+		return err
 	}
 
-	// Set proper permissions
-	if err := os.Chmod(keyPath, 0600); err != nil {
-		return fmt.Errorf("failed to set key permissions: %w", err)
+	if opts.SecurityKey() {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+	} else if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to generate key: %s", string(output))
 	}
 
-	return nil
+	return m.FinishKey(keyPath)
 }
 
-// GetPublicKey returns the content of the public key
+// GetPublicKey returns the content of the public key. When the .pub file is
+// missing it is read from the private key, which for a security key is a stub
+// that holds the public key, so no touch is needed.
 func (m *Manager) GetPublicKey(keyPath string) (string, error) {
-	// Expand home directory
-	if strings.HasPrefix(keyPath, "~/") {
-		home, _ := os.UserHomeDir()
-		keyPath = filepath.Join(home, keyPath[2:])
-	}
-
-	pubKeyPath := keyPath + ".pub"
+	keyPath = expandPath(keyPath)
 
-	data, err := os.ReadFile(pubKeyPath)
-	if err != nil {
+	data, err := os.ReadFile(keyPath + ".pub")
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if _, statErr := os.Stat(keyPath); statErr != nil {
 		return "", fmt.Errorf("failed to read public key: %w", err)
 	}
 
-	return strings.TrimSpace(string(data)), nil
+	output, err := exec.Command("ssh-keygen", "-y", "-P", "", "-f", keyPath).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("failed to read public key: ssh-keygen: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to read public key: ssh-keygen: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
-// TestConnection tests SSH connection to GitHub
-func (m *Manager) TestConnection(hostAlias string) (bool, string) {
-	cmd := exec.Command("ssh", "-T", fmt.Sprintf("git@%s", hostAlias))
+// TestConnection tests the account's SSH connection to GitHub, the same way git will connect
+func (m *Manager) TestConnection(account config.Account) (bool, string) {
+	args := []string{"-T", "git@" + account.SSHHost()}
+	if account.UsesSSHCommand() {
+		args = append([]string{"-i", expandPath(account.SSHKeyPath), "-o", "IdentitiesOnly=yes"}, args...)
+	}
+	cmd := exec.Command("ssh", args...)
 
 	// Set a timeout
 	timer := time.AfterFunc(10*time.Second, func() {
@@ -144,55 +137,54 @@ func (m *Manager) AddToSSHAgent(keyPath string) error {
 	return nil
 }
 
-// UpdateSSHConfig updates ~/.ssh/config with account configurations
-func (m *Manager) UpdateSSHConfig(accounts []config.Account) error {
-	const (
-		startMarker = "# BEGIN GHMM MANAGED SECTION\n"
-		endMarker   = "# END GHMM MANAGED SECTION\n"
-	)
+// Markers delimiting the section of ~/.ssh/config owned by ghmm
+const (
+	sshStartMarker = "# BEGIN GHMM MANAGED SECTION\n"
+	sshEndMarker   = "# END GHMM MANAGED SECTION\n"
+)
 
-	existingContent := ""
+// ConfigPath returns the path to the SSH config file
+func (m *Manager) ConfigPath() string {
+	return m.configFile
+}
 
-	// Read existing config
-	if data, err := os.ReadFile(m.configFile); err == nil {
-		content := string(data)
-
-		// Remove old ghmm section if it exists
-		if strings.Contains(content, startMarker) {
-			parts := strings.Split(content, startMarker)
-			before := parts[0]
-
-			afterParts := strings.Split(content, endMarker)
-			after := ""
-			if len(afterParts) > 1 {
-				after = afterParts[1]
-			}
-
-			existingContent = before + after
-		} else {
-			existingContent = content
-		}
-	}
+// CurrentSSHConfigSection returns the ghmm managed section currently in ~/.ssh/config
+func (m *Manager) CurrentSSHConfigSection() string {
+	return managed.Section(m.configFile, sshStartMarker, sshEndMarker)
+}
 
-	// Generate new config section
+// GenerateSSHConfigSection renders the ghmm managed section for the given accounts
+func (m *Manager) GenerateSSHConfigSection(accounts []config.Account) string {
 	var newSection strings.Builder
-	newSection.WriteString(startMarker)
+	newSection.WriteString(sshStartMarker)
 	newSection.WriteString("# Generated by GitHub Multi-Account Manager\n")
 	newSection.WriteString("# Do not edit this section manually\n\n")
 
 	for _, account := range accounts {
+		// Accounts using core.sshCommand pass their key to ssh directly
+		if account.UsesSSHCommand() {
+			continue
+		}
 		newSection.WriteString(fmt.Sprintf("# %s account\n", account.Name))
 		newSection.WriteString(fmt.Sprintf("Host %s\n", account.HostAlias))
-		newSection.WriteString("   HostName github.com\n")
+		newSection.WriteString(fmt.Sprintf("   HostName %s\n", account.GitHubHost()))
 		newSection.WriteString("   User git\n")
 		newSection.WriteString(fmt.Sprintf("   IdentityFile %s\n", account.SSHKeyPath))
 		newSection.WriteString("   IdentitiesOnly yes\n\n")
 	}
 
-	newSection.WriteString(endMarker)
+	newSection.WriteString(sshEndMarker)
+	return newSection.String()
+}
 
-	// Write updated config
-	finalContent := strings.TrimRight(existingContent, "\n") + "\n\n" + newSection.String()
+// UpdateSSHConfig updates ~/.ssh/config with account configurations
+func (m *Manager) UpdateSSHConfig(accounts []config.Account) error {
+	// Read existing config, replacing any old ghmm section
+	existingContent := ""
+	if data, err := os.ReadFile(m.configFile); err == nil {
+		existingContent = string(data)
+	}
+	finalContent := managed.Replace(existingContent, sshStartMarker, sshEndMarker, m.GenerateSSHConfigSection(accounts))
 
 	if err := os.WriteFile(m.configFile, []byte(finalContent), 0600); err != nil {
 		return fmt.Errorf("failed to write SSH config: %w", err)
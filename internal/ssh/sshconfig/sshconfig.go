@@ -0,0 +1,424 @@
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth matches the recursion limit ssh applies to Include
+const maxIncludeDepth = 16
+
+// HostConfig is what ssh would use when connecting to a host
+type HostConfig struct {
+	Host           string // Name as given on the command line
+	HostName       string
+	User           string
+	Port           int
+	IdentityFiles  []string
+	IdentitiesOnly bool
+	IdentityAgent  string
+	// Origins records the file:line each option was taken from, keyed by keyword
+	Origins map[string][]string
+}
+
+// Option is a single keyword and its arguments
+type Option struct {
+	Keyword string // Lowercased keyword, e.g. "identityfile"
+	Args    []string
+	File    string
+	Line    int
+}
+
+// Block is a Host or Match section. Options before the first Host line
+// belong to an implicit block that matches every host.
+type Block struct {
+	Kind     string   // "host", "match", or "" for the implicit global block
+	Patterns []string // Host patterns or Match criteria, as written
+	Options  []Option
+	File     string
+	Line     int
+}
+
+// Config is a parsed ssh_config with its Include directives expanded
+type Config struct {
+	Blocks []*Block
+}
+
+// Load parses an ssh_config file, following Include directives
+func Load(path string) (*Config, error) {
+	c := &Config{}
+	global := &Block{File: path}
+	c.Blocks = append(c.Blocks, global)
+	if err := c.parseFile(path, global, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseFile reads path into c. current is the block in effect at the
+// point of inclusion; options before the file's first Host or Match apply to it.
+func (c *Config) parseFile(path string, current *Block, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("%s: too many nested includes", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		keyword, args, err := splitLine(raw)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if keyword == "" {
+			continue
+		}
+
+		switch keyword {
+		case "host", "match":
+			if len(args) == 0 {
+				return fmt.Errorf("%s:%d: %s requires an argument", path, lineNo, keyword)
+			}
+			current = &Block{Kind: keyword, Patterns: args, File: path, Line: lineNo}
+			c.Blocks = append(c.Blocks, current)
+
+		case "include":
+			// Included files may start their own Host blocks, so options that
+			// follow the Include in this file need a fresh copy of the block
+			for _, pattern := range args {
+				matches, err := filepath.Glob(resolveIncludePath(pattern, path))
+				if err != nil {
+					return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+				}
+				sort.Strings(matches)
+				for _, match := range matches {
+					scope := &Block{Kind: current.Kind, Patterns: current.Patterns, File: match}
+					c.Blocks = append(c.Blocks, scope)
+					if err := c.parseFile(match, scope, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+			resumed := &Block{Kind: current.Kind, Patterns: current.Patterns, File: path, Line: lineNo}
+			c.Blocks = append(c.Blocks, resumed)
+			current = resumed
+
+		default:
+			current.Options = append(current.Options, Option{
+				Keyword: keyword,
+				Args:    args,
+				File:    path,
+				Line:    lineNo,
+			})
+		}
+	}
+
+	return nil
+}
+
+// splitLine splits a config line into a lowercased keyword and its arguments.
+// Both "Keyword value" and "Keyword=value" forms are accepted, and arguments
+// may be double quoted to include spaces.
+func splitLine(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, nil
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end == -1 {
+		return strings.ToLower(line), nil, nil
+	}
+	keyword := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+
+	var args []string
+	var arg strings.Builder
+	inQuotes, hasArg := false, false
+	for i := 0; i < len(rest); i++ {
+		ch := rest[i]
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case !inQuotes && (ch == ' ' || ch == '\t'):
+			if hasArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				hasArg = false
+			}
+		case !inQuotes && ch == '#' && !hasArg:
+			// Trailing comment
+			i = len(rest)
+		default:
+			arg.WriteByte(ch)
+			hasArg = true
+		}
+	}
+	if inQuotes {
+		return "", nil, fmt.Errorf("unterminated quote")
+	}
+	if hasArg {
+		args = append(args, arg.String())
+	}
+
+	return keyword, args, nil
+}
+
+// resolveIncludePath expands ~ and makes relative Include paths relative to ~/.ssh
+func resolveIncludePath(pattern, configPath string) string {
+	pattern = expandTilde(pattern)
+	if filepath.IsAbs(pattern) {
+		return pattern
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".ssh", pattern)
+	}
+	return filepath.Join(filepath.Dir(configPath), pattern)
+}
+
+// Aliases returns every literal host name that appears in a Host line, in order.
+// Wildcard and negated patterns are skipped.
+func (c *Config) Aliases() []string {
+	seen := map[string]bool{}
+	var aliases []string
+	for _, b := range c.Blocks {
+		if b.Kind != "host" || b.Line == 0 {
+			continue
+		}
+		for _, pattern := range b.Patterns {
+			if strings.ContainsAny(pattern, "*?!") || seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			aliases = append(aliases, pattern)
+		}
+	}
+	return aliases
+}
+
+// Resolve answers "what would ssh use for host". Like ssh, the first value
+// obtained for each option wins, except IdentityFile which accumulates.
+func (c *Config) Resolve(host string) HostConfig {
+	resolved := HostConfig{Host: host, Origins: map[string][]string{}}
+	set := map[string]bool{}
+
+	for _, b := range c.Blocks {
+		if !c.blockMatches(b, host, resolved) {
+			continue
+		}
+		for _, opt := range b.Options {
+			if len(opt.Args) == 0 {
+				continue
+			}
+			origin := fmt.Sprintf("%s:%d", opt.File, opt.Line)
+			if opt.Keyword == "identityfile" {
+				resolved.IdentityFiles = append(resolved.IdentityFiles, opt.Args[0])
+				resolved.Origins[opt.Keyword] = append(resolved.Origins[opt.Keyword], origin)
+				continue
+			}
+			if set[opt.Keyword] {
+				continue
+			}
+			set[opt.Keyword] = true
+			resolved.Origins[opt.Keyword] = []string{origin}
+
+			switch opt.Keyword {
+			case "hostname":
+				resolved.HostName = opt.Args[0]
+			case "user":
+				resolved.User = opt.Args[0]
+			case "port":
+				resolved.Port, _ = strconv.Atoi(opt.Args[0])
+			case "identitiesonly":
+				resolved.IdentitiesOnly = strings.EqualFold(opt.Args[0], "yes")
+			case "identityagent":
+				resolved.IdentityAgent = opt.Args[0]
+			}
+		}
+	}
+
+	if resolved.HostName == "" {
+		resolved.HostName = host
+	} else {
+		resolved.HostName = strings.ReplaceAll(resolved.HostName, "%h", host)
+	}
+	if resolved.User == "" {
+		resolved.User = localUser()
+	}
+	if resolved.Port == 0 {
+		resolved.Port = 22
+	}
+
+	for i, file := range resolved.IdentityFiles {
+		resolved.IdentityFiles[i] = expandTokens(file, resolved)
+	}
+	if resolved.IdentityAgent != "" {
+		resolved.IdentityAgent = expandTokens(resolved.IdentityAgent, resolved)
+	}
+
+	return resolved
+}
+
+// blockMatches reports whether a block applies when connecting to host
+func (c *Config) blockMatches(b *Block, host string, resolved HostConfig) bool {
+	switch b.Kind {
+	case "":
+		return true
+	case "host":
+		return MatchHost(b.Patterns, host)
+	case "match":
+		return matchCriteria(b.Patterns, host, resolved)
+	}
+	return false
+}
+
+// MatchHost evaluates a Host line's patterns. A negated pattern that matches
+// rejects the host even if another pattern matches it.
+func MatchHost(patterns []string, host string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		for _, p := range strings.Split(pattern, ",") {
+			negated := strings.HasPrefix(p, "!")
+			if !matchPattern(strings.TrimPrefix(p, "!"), host) {
+				continue
+			}
+			if negated {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchCriteria evaluates Match criteria. Criteria that can't be evaluated
+// offline, such as exec, are treated as not matching.
+func matchCriteria(criteria []string, host string, resolved HostConfig) bool {
+	target := resolved.HostName
+	if target == "" {
+		target = host
+	}
+
+	for i := 0; i < len(criteria); i++ {
+		criterion := strings.ToLower(criteria[i])
+		negated := strings.HasPrefix(criterion, "!")
+		criterion = strings.TrimPrefix(criterion, "!")
+
+		var ok bool
+		switch criterion {
+		case "all":
+			ok = true
+		case "canonical", "final":
+			// ghmm never canonicalizes, so treat these as satisfied
+			ok = true
+		case "host", "originalhost", "user", "localuser", "exec":
+			if i+1 >= len(criteria) {
+				return false
+			}
+			arg := criteria[i+1]
+			i++
+			switch criterion {
+			case "host":
+				ok = MatchHost([]string{arg}, target)
+			case "originalhost":
+				ok = MatchHost([]string{arg}, host)
+			case "user":
+				user := resolved.User
+				if user == "" {
+					user = localUser()
+				}
+				ok = MatchHost([]string{arg}, user)
+			case "localuser":
+				ok = MatchHost([]string{arg}, localUser())
+			case "exec":
+				ok = false
+			}
+		default:
+			return false
+		}
+
+		if ok == negated {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPattern matches ssh's * and ? wildcards
+func matchPattern(pattern, s string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '*':
+		for i := 0; i <= len(s); i++ {
+			if matchPattern(pattern[1:], s[i:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		return s != "" && matchPattern(pattern[1:], s[1:])
+	default:
+		return s != "" && strings.EqualFold(pattern[:1], s[:1]) && matchPattern(pattern[1:], s[1:])
+	}
+}
+
+// expandTokens expands ~ and the % tokens ssh supports in IdentityFile and IdentityAgent
+func expandTokens(value string, resolved HostConfig) string {
+	value = expandTilde(value)
+	if !strings.Contains(value, "%") {
+		return value
+	}
+
+	home, _ := os.UserHomeDir()
+	replacements := map[byte]string{
+		'%': "%",
+		'd': home,
+		'u': localUser(),
+		'h': resolved.HostName,
+		'n': resolved.Host,
+		'r': resolved.User,
+		'p': strconv.Itoa(resolved.Port),
+		'i': strconv.Itoa(os.Getuid()),
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '%' && i+1 < len(value) {
+			if r, ok := replacements[value[i+1]]; ok {
+				b.WriteString(r)
+				i++
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+func expandTilde(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+func localUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,237 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadTestdata loads testdata/home/.ssh/config with HOME pointing at testdata/home
+func loadTestdata(t *testing.T) (*Config, string) {
+	t.Helper()
+	home, err := filepath.Abs(filepath.Join("testdata", "home"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+
+	c, err := Load(filepath.Join(home, ".ssh", "config"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return c, home
+}
+
+func TestResolve(t *testing.T) {
+	c, home := loadTestdata(t)
+	key := func(name string) string { return filepath.Join(home, ".ssh", name) }
+
+	tests := []struct {
+		host           string
+		hostName       string
+		user           string
+		port           int
+		identityFiles  []string
+		identitiesOnly bool
+		identityAgent  string
+	}{
+		{
+			host:           "github-work",
+			hostName:       "github.com",
+			user:           "git",
+			port:           2222,
+			identityFiles:  []string{key("id_work"), key("id_github-work"), key("id_default")},
+			identitiesOnly: true,
+			identityAgent:  key("agent-git.sock"),
+		},
+		{
+			// Defined in an included file, which comes before the github-* block
+			host:          "github-personal",
+			hostName:      "github.com",
+			user:          "git",
+			port:          2222,
+			identityFiles: []string{key("id_personal"), key("id_github-personal"), key("id_default")},
+			identityAgent: key("agent-git.sock"),
+		},
+		{
+			// Excluded from github-* by the negated pattern
+			host:          "github-legacy",
+			hostName:      "old.example.com",
+			user:          "fallback",
+			port:          22,
+			identityFiles: []string{key("id_default")},
+		},
+		{
+			// Included with a ~/ path, with a quoted IdentityFile and Port=value
+			host:          "ghe",
+			hostName:      "ghe.example.org",
+			user:          "fallback",
+			port:          2200,
+			identityFiles: []string{key("id ghe"), key("id_default")},
+		},
+		{
+			host:          "example.com",
+			hostName:      "example.com",
+			user:          "fallback",
+			port:          22,
+			identityFiles: []string{key("id_default")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got := c.Resolve(tt.host)
+			if got.HostName != tt.hostName || got.User != tt.user || got.Port != tt.port {
+				t.Errorf("Resolve() = {HostName: %s, User: %s, Port: %d}, want {%s, %s, %d}",
+					got.HostName, got.User, got.Port, tt.hostName, tt.user, tt.port)
+			}
+			if strings.Join(got.IdentityFiles, ",") != strings.Join(tt.identityFiles, ",") {
+				t.Errorf("IdentityFiles = %q, want %q", got.IdentityFiles, tt.identityFiles)
+			}
+			if got.IdentitiesOnly != tt.identitiesOnly {
+				t.Errorf("IdentitiesOnly = %v, want %v", got.IdentitiesOnly, tt.identitiesOnly)
+			}
+			if got.IdentityAgent != tt.identityAgent {
+				t.Errorf("IdentityAgent = %q, want %q", got.IdentityAgent, tt.identityAgent)
+			}
+		})
+	}
+}
+
+func TestResolveOrigins(t *testing.T) {
+	c, home := loadTestdata(t)
+
+	got := c.Resolve("github-personal")
+	want := filepath.Join(home, ".ssh", "config.d", "10-personal.conf") + ":3"
+	if origins := got.Origins["user"]; len(origins) != 1 || origins[0] != want {
+		t.Errorf("Origins[user] = %q, want [%s]", origins, want)
+	}
+	if origins := got.Origins["identityfile"]; len(origins) != 3 {
+		t.Errorf("Origins[identityfile] = %q, want 3 entries", origins)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	c, _ := loadTestdata(t)
+
+	// notes.txt is not matched by the *.conf glob, and patterns with wildcards or ! are skipped
+	want := []string{"github-personal", "ghe", "github-work", "github-legacy"}
+	if got := c.Aliases(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Aliases() = %q, want %q", got, want)
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		host     string
+		want     bool
+	}{
+		{[]string{"github.com"}, "github.com", true},
+		{[]string{"GitHub.com"}, "github.com", true},
+		{[]string{"github.com"}, "gitlab.com", false},
+		{[]string{"github-*"}, "github-work", true},
+		{[]string{"github-*"}, "github", false},
+		{[]string{"github-?"}, "github-a", true},
+		{[]string{"github-?"}, "github-ab", false},
+		{[]string{"*"}, "anything", true},
+		{[]string{"github-*", "!github-legacy"}, "github-work", true},
+		{[]string{"github-*", "!github-legacy"}, "github-legacy", false},
+		{[]string{"!github-legacy", "github-*"}, "github-legacy", false},
+		{[]string{"!github-legacy"}, "github-work", false},
+		{[]string{"a,b,!c"}, "b", true},
+		{[]string{"a,b,!c"}, "c", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchHost(tt.patterns, tt.host); got != tt.want {
+			t.Errorf("MatchHost(%q, %q) = %v, want %v", tt.patterns, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		keyword string
+		args    []string
+	}{
+		{"", "", nil},
+		{"   # comment", "", nil},
+		{"HostName github.com", "hostname", []string{"github.com"}},
+		{"\tPort=2200", "port", []string{"2200"}},
+		{"Port = 2200", "port", []string{"2200"}},
+		{"Host a b  c", "host", []string{"a", "b", "c"}},
+		{`IdentityFile "~/.ssh/id with space"`, "identityfile", []string{"~/.ssh/id with space"}},
+		{"User git # trailing comment", "user", []string{"git"}},
+		{"ForwardAgent", "forwardagent", nil},
+	}
+
+	for _, tt := range tests {
+		keyword, args, err := splitLine(tt.line)
+		if err != nil {
+			t.Errorf("splitLine(%q) error = %v", tt.line, err)
+			continue
+		}
+		if keyword != tt.keyword || strings.Join(args, "|") != strings.Join(tt.args, "|") {
+			t.Errorf("splitLine(%q) = %q, %q, want %q, %q", tt.line, keyword, args, tt.keyword, tt.args)
+		}
+	}
+
+	if _, _, err := splitLine(`IdentityFile "unterminated`); err == nil {
+		t.Error("splitLine() with an unterminated quote returned no error")
+	}
+}
+
+func TestIncludeInsideHostBlock(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Options at the top of an included file belong to the including Host block,
+	// and so do options after the Include line
+	write("config", "Host work\n    Include "+filepath.Join(dir, "work.conf")+"\n    Port 2222\n")
+	write("work.conf", "User git\n\nHost other\n    User nobody\n")
+
+	c, err := Load(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := c.Resolve("work"); got.User != "git" || got.Port != 2222 {
+		t.Errorf("Resolve(work) = {User: %s, Port: %d}, want {git, 2222}", got.User, got.Port)
+	}
+	if got := c.Resolve("other"); got.User != "nobody" || got.Port != 22 {
+		t.Errorf("Resolve(other) = {User: %s, Port: %d}, want {nobody, 22}", got.User, got.Port)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	tests := []struct {
+		name, content, want string
+	}{
+		{"host without pattern", "Host\n", "host requires an argument"},
+		{"unterminated quote", "Host a\n    User \"git\n", ":2: unterminated quote"},
+		{"include loop", "Include " + filepath.Join(dir, "config") + "\n", "too many nested includes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/donbowman/github-multi-account-manager/internal/diff"
+)
+
+var (
+	diffAddStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("green"))
+
+	diffDeleteStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("red"))
+
+	diffHeaderStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("205")).
+			Bold(true)
+
+	mutedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("yellow"))
+)
+
+// applyTarget identifies a group of files written by apply
+type applyTarget int
+
+const (
+	targetSSH applyTarget = iota
+	targetGit
+	targetShell
+)
+
+var applyTargetNames = [...]string{"SSH", "Git", "Shell"}
+
+// fileChange is a pending change to a single file shown in the apply preview
+type fileChange struct {
+	target applyTarget
+	path   string
+	before string
+	after  string
+	note   string // Side effect worth calling out above the diff
+}
+
+// pendingChanges computes what apply would write without touching any file
+func (m model) pendingChanges() []fileChange {
+	accounts := m.config.ListAccounts()
+	defaultAcc := m.config.GetDefaultAccount()
+
+	changes := []fileChange{
+		{
+			target: targetSSH,
+			path:   m.sshManager.ConfigPath(),
+			before: m.sshManager.CurrentSSHConfigSection(),
+			after:  m.sshManager.GenerateSSHConfigSection(accounts),
+		},
+		{
+			target: targetGit,
+			path:   m.gitManager.GitconfigPath(),
+			before: m.gitManager.CurrentGitconfigSection(),
+			after:  m.gitManager.GenerateGitconfigSection(accounts, m.config.AllowedSignersFile()),
+		},
+		{
+			target: targetGit,
+			path:   m.config.AllowedSignersFile(),
+			before: m.gitManager.CurrentAllowedSigners(m.config.AllowedSignersFile()),
+			after:  m.gitManager.GenerateAllowedSigners(accounts, m.config.SignerFiles),
+		},
+	}
+
+	for _, acc := range accounts {
+		path := m.gitManager.AccountGitconfigPath(acc.Name)
+		change := fileChange{
+			target: targetGit,
+			path:   path,
+			before: readFileOrEmpty(path),
+			after:  m.gitManager.AccountGitconfigContent(acc),
+		}
+		if acc.UsesHTTPS() {
+			change.note = fmt.Sprintf("⚠️  Resets credential helpers for https://%s (such as gh's) to ghmm-cli credential in %s's directories",
+				acc.GitHubHost(), acc.Name)
+		}
+		changes = append(changes, change)
+	}
+
+	changes = append(changes, fileChange{
+		target: targetShell,
+		path:   m.shellManager.ConfigFile,
+		before: m.shellManager.CurrentShellSection(),
+		after:  m.shellManager.GenerateShellSection(accounts, defaultAcc),
+	})
+
+	return changes
+}
+
+func (m model) startApplyPreview() model {
+	// Generated files would carry the problems into ssh and git
+	if err := m.config.Problems(); err != nil {
+		m.errorMsg = fmt.Sprintf("❌ Fix the config before applying: %v", err)
+		m.statusMsg = ""
+		return m
+	}
+
+	m.applyChanges = m.pendingChanges()
+	m.applyTargets = [len(applyTargetNames)]bool{true, true, true}
+	m.preview = viewport.New(m.previewWidth(), m.previewHeight())
+	m.preview.SetContent(m.renderChanges())
+	m.mode = viewApplyPreview
+	m.statusMsg = ""
+	m.errorMsg = ""
+	return m
+}
+
+func (m model) handleApplyPreviewInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "n", "q":
+		m.mode = viewTable
+		m.applyChanges = nil
+		m.statusMsg = "Apply cancelled, nothing was written"
+		m.errorMsg = ""
+		return m, nil
+
+	case "1", "2", "3":
+		target := int(msg.String()[0] - '1')
+		m.applyTargets[target] = !m.applyTargets[target]
+		m.preview.SetContent(m.renderChanges())
+		return m, nil
+
+	case "y", "enter":
+		m.mode = viewTable
+		m.applyChanges = nil
+		if !slices.Contains(m.applyTargets[:], true) {
+			m.statusMsg = "Nothing selected to apply"
+			m.errorMsg = ""
+			return m, nil
+		}
+		m = m.applyConfigs()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.preview, cmd = m.preview.Update(msg)
+	return m, cmd
+}
+
+// renderChanges renders a colored diff of every pending file change
+func (m model) renderChanges() string {
+	var b strings.Builder
+
+	for _, change := range m.applyChanges {
+		lines := diff.Lines(change.before, change.after)
+		enabled := m.applyTargets[change.target]
+
+		header := fmt.Sprintf("── [%s] %s", applyTargetNames[change.target], change.path)
+		switch {
+		case !enabled:
+			b.WriteString(mutedStyle.Render(header + " (skipped)"))
+			b.WriteString("\n\n")
+			continue
+		case !diff.HasChanges(lines):
+			b.WriteString(diffHeaderStyle.Render(header))
+			b.WriteString(mutedStyle.Render(" (unchanged)"))
+			b.WriteString("\n\n")
+			continue
+		}
+
+		b.WriteString(diffHeaderStyle.Render(header))
+		b.WriteString("\n")
+		if change.note != "" {
+			b.WriteString(warningStyle.Render(change.note))
+			b.WriteString("\n")
+		}
+		for _, line := range lines {
+			switch line.Op {
+			case diff.Insert:
+				b.WriteString(diffAddStyle.Render("+ " + line.Text))
+			case diff.Delete:
+				b.WriteString(diffDeleteStyle.Render("- " + line.Text))
+			default:
+				b.WriteString("  " + line.Text)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func (m model) renderApplyPreview() string {
+	title := titleStyle.Render("Review Changes")
+
+	var toggles []string
+	for i, name := range applyTargetNames {
+		box := "[ ]"
+		if m.applyTargets[i] {
+			box = "[x]"
+		}
+		toggles = append(toggles, fmt.Sprintf("%d:%s %s", i+1, box, name))
+	}
+
+	help := helpStyle.Render("1/2/3:toggle target • ↑/↓/pgup/pgdn:scroll • y/enter:apply • esc:cancel")
+
+	return fmt.Sprintf("%s\n%s\n\n%s\n%s\n",
+		title,
+		infoStyle.Render(strings.Join(toggles, "   ")),
+		baseStyle.Render(m.preview.View()),
+		help,
+	)
+}
+
+// previewWidth returns the viewport width for the current terminal size
+func (m model) previewWidth() int {
+	if m.width == 0 {
+		return 100
+	}
+	return max(m.width-2, 20)
+}
+
+// previewHeight returns the viewport height for the current terminal size
+func (m model) previewHeight() int {
+	if m.height == 0 {
+		return 20
+	}
+	// Leave room for the title, target toggles, border and help line
+	return max(m.height-9, 5)
+}
+
+// readFileOrEmpty returns the contents of path, or "" if it can't be read
+func readFileOrEmpty(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,439 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/git"
+	"github.com/donbowman/github-multi-account-manager/internal/workspace"
+)
+
+// Focus positions in the clone dialog
+const (
+	cloneFocusURL = iota
+	cloneFocusAccount
+	cloneFocusDest
+	cloneFocusCount
+)
+
+// cloneOutputMsg carries a chunk of git clone output
+type cloneOutputMsg struct {
+	text string
+}
+
+// cloneDoneMsg is sent when git clone exits
+type cloneDoneMsg struct {
+	err error
+}
+
+// cloneState holds the clone dialog inputs and the running clone's output
+type cloneState struct {
+	urlInput   textinput.Model
+	destInput  textinput.Model
+	account    int
+	focused    int
+	destEdited bool // Stop following the URL once the user types a destination
+	picked     bool // Stop routing by owner once the user picks an account
+	routeNote  string
+	running    bool
+	cancelled  bool
+	finished   bool
+	cmd        *exec.Cmd
+	lines      []string
+	pendingCR  bool
+	output     viewport.Model
+	stream     chan tea.Msg
+}
+
+func (m model) startClone() model {
+	urlInput := textinput.New()
+	urlInput.Prompt = "Repository: "
+	urlInput.Placeholder = "https://github.com/owner/repo or owner/repo"
+	urlInput.CharLimit = 300
+	urlInput.Width = 60
+	urlInput.Focus()
+
+	destInput := textinput.New()
+	destInput.Prompt = "Destination: "
+	destInput.CharLimit = 300
+	destInput.Width = 60
+
+	account := m.accountIndex(m.config.GetDefaultAccount())
+
+	m.clone = cloneState{
+		urlInput:  urlInput,
+		destInput: destInput,
+		account:   max(account, 0),
+		output:    viewport.New(m.previewWidth(), max(m.previewHeight()-6, 5)),
+	}
+	m.mode = viewClone
+	m.statusMsg = ""
+	m.errorMsg = ""
+	return m.updateCloneDestination()
+}
+
+// accountIndex returns the position of the named account in the config, or -1
+func (m model) accountIndex(name string) int {
+	for i, acc := range m.config.ListAccounts() {
+		if acc.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// cloneAccount returns the account currently picked in the clone dialog
+func (m model) cloneAccount() (config.Account, bool) {
+	accounts := m.config.ListAccounts()
+	if m.clone.account < 0 || m.clone.account >= len(accounts) {
+		return config.Account{}, false
+	}
+	return accounts[m.clone.account], true
+}
+
+// updateCloneDestination pre-fills the destination under the picked account's directory
+func (m model) updateCloneDestination() model {
+	if m.clone.destEdited {
+		return m
+	}
+
+	account, ok := m.cloneAccount()
+	if !ok {
+		return m
+	}
+
+	dest := account.PrimaryDirectory()
+	if remote, ok := workspace.ParseRepoSpec(m.clone.urlInput.Value()); ok {
+		dest = filepath.Join(dest, remote.Repo)
+	}
+	m.clone.destInput.SetValue(dest)
+	return m
+}
+
+// routeClone picks the account for the repository being cloned from its owner,
+// falling back to the current directory and then the default account
+func (m model) routeClone() model {
+	if m.clone.picked {
+		return m
+	}
+
+	remote, ok := workspace.ParseRepoSpec(m.clone.urlInput.Value())
+	if !ok {
+		m.clone.routeNote = ""
+		return m
+	}
+
+	cwd, _ := os.Getwd()
+	route := m.config.RouteRepository(remote.Owner, cwd)
+	m.clone.routeNote = route.Reason
+	if route.Account != nil {
+		if i := m.accountIndex(route.Account.Name); i >= 0 {
+			m.clone.account = i
+		}
+	}
+	return m
+}
+
+func (m model) setCloneFocus(focus int) model {
+	m.clone.urlInput.Blur()
+	m.clone.destInput.Blur()
+	m.clone.focused = (focus + cloneFocusCount) % cloneFocusCount
+	switch m.clone.focused {
+	case cloneFocusURL:
+		m.clone.urlInput.Focus()
+	case cloneFocusDest:
+		m.clone.destInput.Focus()
+	}
+	return m
+}
+
+func (m model) handleCloneInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// While git runs esc/ctrl+c stops it, afterwards any of esc/enter/q closes
+	if m.clone.running || m.clone.finished {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			if m.clone.running {
+				return m.cancelClone(), nil
+			}
+			m.mode = viewTable
+			m.clone = cloneState{}
+			return m, nil
+		case "enter", "q":
+			if !m.clone.running {
+				m.mode = viewTable
+				m.clone = cloneState{}
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.clone.output, cmd = m.clone.output.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.mode = viewTable
+		m.clone = cloneState{}
+		return m, nil
+
+	case "tab", "down":
+		return m.setCloneFocus(m.clone.focused + 1), nil
+
+	case "shift+tab", "up":
+		return m.setCloneFocus(m.clone.focused - 1), nil
+
+	case "enter":
+		return m.runClone()
+	}
+
+	var cmd tea.Cmd
+	switch m.clone.focused {
+	case cloneFocusURL:
+		m.clone.urlInput, cmd = m.clone.urlInput.Update(msg)
+		m = m.routeClone().updateCloneDestination()
+
+	case cloneFocusAccount:
+		count := len(m.config.ListAccounts())
+		if count == 0 {
+			break
+		}
+		switch msg.String() {
+		case "left", "h":
+			m.clone.account = (m.clone.account - 1 + count) % count
+		case "right", "l", " ":
+			m.clone.account = (m.clone.account + 1) % count
+		}
+		m.clone.picked = true
+		m.clone.routeNote = ""
+		m = m.updateCloneDestination()
+
+	case cloneFocusDest:
+		before := m.clone.destInput.Value()
+		m.clone.destInput, cmd = m.clone.destInput.Update(msg)
+		if m.clone.destInput.Value() != before {
+			m.clone.destEdited = true
+		}
+	}
+
+	return m, cmd
+}
+
+func (m model) runClone() (tea.Model, tea.Cmd) {
+	remote, ok := workspace.ParseRepoSpec(m.clone.urlInput.Value())
+	if !ok {
+		m.errorMsg = "❌ Enter a GitHub URL or owner/repo"
+		return m, nil
+	}
+
+	account, ok := m.cloneAccount()
+	if !ok {
+		m.errorMsg = "❌ Add an account before cloning"
+		return m, nil
+	}
+
+	dest := config.ExpandHome(strings.TrimSpace(m.clone.destInput.Value()))
+	if dest == "" {
+		m.errorMsg = "❌ Destination is required"
+		return m, nil
+	}
+	if _, err := os.Stat(dest); err == nil {
+		m.errorMsg = fmt.Sprintf("❌ %s already exists", dest)
+		return m, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		m.errorMsg = fmt.Sprintf("❌ Failed to create %s: %v", filepath.Dir(dest), err)
+		return m, nil
+	}
+
+	url := git.SSHCloneURL(account.SSHHost(), remote)
+	sshCommand := ""
+	if account.UsesSSHCommand() {
+		sshCommand = account.SSHCommand()
+	}
+	stream, cmd, err := streamClone(url, dest, sshCommand)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("❌ Failed to start git: %v", err)
+		return m, nil
+	}
+
+	m.clone.running = true
+	m.clone.cmd = cmd
+	m.clone.stream = stream
+	m.clone.lines = []string{fmt.Sprintf("$ git clone %s %s", url, dest), ""}
+	m.clone.output.SetContent(strings.Join(m.clone.lines, "\n"))
+	m.statusMsg = fmt.Sprintf("Cloning with %s account...", account.Name)
+	m.errorMsg = ""
+	return m, waitForClone(stream)
+}
+
+// cancelClone interrupts the running clone; git removes the partial
+// checkout and exits, which arrives as a failed cloneDoneMsg
+func (m model) cancelClone() model {
+	if m.clone.cancelled || m.clone.cmd == nil || m.clone.cmd.Process == nil {
+		return m
+	}
+	if err := m.clone.cmd.Process.Signal(os.Interrupt); err != nil {
+		m.clone.cmd.Process.Kill()
+	}
+	m.clone.cancelled = true
+	m.statusMsg = "Cancelling clone..."
+	m.errorMsg = ""
+	return m
+}
+
+// streamClone starts git clone and forwards its combined output as messages
+func streamClone(url, dest, sshCommand string) (chan tea.Msg, *exec.Cmd, error) {
+	reader, writer := io.Pipe()
+	cmd := git.CloneCommand(url, dest, sshCommand)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		writer.Close()
+		exited <- err
+	}()
+
+	stream := make(chan tea.Msg)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := reader.Read(buf)
+			if n > 0 {
+				stream <- cloneOutputMsg{text: string(buf[:n])}
+			}
+			if err != nil {
+				break
+			}
+		}
+		stream <- cloneDoneMsg{err: <-exited}
+		close(stream)
+	}()
+
+	return stream, cmd, nil
+}
+
+// waitForClone waits for the next message from a running clone
+func waitForClone(stream chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-stream
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// appendCloneOutput adds output to the log, treating \r the way a terminal does
+// so git's progress counters overwrite themselves instead of scrolling
+func (m model) appendCloneOutput(text string) model {
+	lines := m.clone.lines
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			lines = append(lines, "")
+			m.clone.pendingCR = false
+		case r == '\r':
+			m.clone.pendingCR = true
+		default:
+			if m.clone.pendingCR {
+				lines[len(lines)-1] = ""
+				m.clone.pendingCR = false
+			}
+			lines[len(lines)-1] += string(r)
+		}
+	}
+	m.clone.lines = lines
+	m.clone.output.SetContent(strings.Join(lines, "\n"))
+	m.clone.output.GotoBottom()
+	return m
+}
+
+func (m model) handleCloneOutput(msg cloneOutputMsg) (tea.Model, tea.Cmd) {
+	m = m.appendCloneOutput(msg.text)
+	return m, waitForClone(m.clone.stream)
+}
+
+func (m model) handleCloneDone(msg cloneDoneMsg) (tea.Model, tea.Cmd) {
+	m.clone.running = false
+	m.clone.finished = true
+	m.clone.stream = nil
+	m.clone.cmd = nil
+
+	switch {
+	case msg.err != nil && m.clone.cancelled:
+		m.errorMsg = "❌ Clone failed: cancelled"
+		m.statusMsg = ""
+	case msg.err != nil:
+		m.errorMsg = fmt.Sprintf("❌ Clone failed: %v", msg.err)
+		m.statusMsg = ""
+	default:
+		m.statusMsg = fmt.Sprintf("✓ Cloned into %s", m.clone.destInput.Value())
+		m.errorMsg = ""
+	}
+	return m, nil
+}
+
+func (m model) renderClone() string {
+	title := titleStyle.Render("Clone Repository")
+
+	var status string
+	if m.errorMsg != "" {
+		status = errorStyle.Render(m.errorMsg)
+	} else if m.statusMsg != "" {
+		status = statusStyle.Render(m.statusMsg)
+	}
+
+	if m.clone.running || m.clone.finished {
+		help := helpStyle.Render("↑/↓/pgup/pgdn:scroll • esc:cancel clone")
+		if m.clone.finished {
+			help = helpStyle.Render("↑/↓/pgup/pgdn:scroll • esc/enter:close")
+		}
+		return fmt.Sprintf("%s\n\n%s\n\n%s\n%s\n",
+			title,
+			baseStyle.Render(m.clone.output.View()),
+			status,
+			help,
+		)
+	}
+
+	accountName := "(no accounts)"
+	if account, ok := m.cloneAccount(); ok {
+		accountName = fmt.Sprintf("%s (%s)", account.Name, account.SSHModeDescription())
+	}
+	accountLine := "Account: ◀ " + accountName + " ▶"
+	if m.clone.focused == cloneFocusAccount {
+		accountLine = infoStyle.Render(accountLine)
+	}
+
+	if m.clone.routeNote != "" {
+		accountLine += "\n" + mutedStyle.Render("  "+m.clone.routeNote)
+	}
+
+	form := fmt.Sprintf("\n%s\n\n%s\n\n%s\n",
+		m.clone.urlInput.View(),
+		accountLine,
+		m.clone.destInput.View(),
+	)
+
+	help := helpStyle.Render("tab/↓:next • shift+tab/↑:prev • ←/→:change account • enter:clone • esc:cancel")
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n%s\n",
+		title,
+		baseStyle.Render(form),
+		status,
+		help,
+	)
+}
@@ -0,0 +1,199 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/donbowman/github-multi-account-manager/internal/ssh"
+)
+
+// keygenState holds the key type chooser shown before generating a key
+type keygenState struct {
+	signing  bool // Generate the separate signing key rather than the authentication key
+	choice   int  // Index into ssh.KeyChoices
+	returnTo viewMode
+}
+
+// keyGeneratedMsg is sent when ssh-keygen exits after prompting for a security key touch
+type keyGeneratedMsg struct {
+	signing bool
+	err     error
+}
+
+// keygenPath returns where the selected account's key is generated
+func (m model) keygenPath(signing bool) (string, bool) {
+	account, ok := m.selectedAccount()
+	if !ok {
+		return "", false
+	}
+	if signing {
+		return account.DefaultSigningKeyPath(), true
+	}
+	return account.SSHKeyPath, true
+}
+
+// startKeygen opens the key type chooser for the selected account's
+// authentication key, or its separate signing key when signing is set
+func (m model) startKeygen(signing bool) model {
+	account, ok := m.selectedAccount()
+	if !ok {
+		m.errorMsg = "❌ No account selected"
+		m.statusMsg = ""
+		return m
+	}
+	path, _ := m.keygenPath(signing)
+
+	// Check if key already exists
+	if _, err := os.Stat(path); err == nil {
+		if signing && !account.HasSeparateSigningKey() {
+			// A signing key from an earlier run that was never recorded
+			return m.finishKeygen(keyGeneratedMsg{signing: true})
+		}
+		kind := "SSH key"
+		if signing {
+			kind = "Signing key"
+		}
+		m.errorMsg = fmt.Sprintf("⚠️  %s already exists for %s", kind, account.Name)
+		m.statusMsg = ""
+		return m
+	}
+
+	m.keygen = keygenState{signing: signing, returnTo: m.mode}
+	m.mode = viewKeygen
+	m.statusMsg = ""
+	m.errorMsg = ""
+	return m
+}
+
+func (m model) handleKeygenInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.mode = m.keygen.returnTo
+		return m, nil
+	case "up", "k":
+		if m.keygen.choice > 0 {
+			m.keygen.choice--
+		}
+	case "down", "j":
+		if m.keygen.choice < len(ssh.KeyChoices)-1 {
+			m.keygen.choice++
+		}
+	case "enter":
+		return m.runKeygen()
+	}
+	return m, nil
+}
+
+// runKeygen generates the key with the chosen options. Security keys need a
+// touch (and a PIN for resident keys), so ssh-keygen gets the terminal.
+func (m model) runKeygen() (tea.Model, tea.Cmd) {
+	account, _ := m.selectedAccount()
+	path, _ := m.keygenPath(m.keygen.signing)
+	opts := ssh.KeyChoices[m.keygen.choice]
+	signing := m.keygen.signing
+	m.mode = m.keygen.returnTo
+
+	if !opts.SecurityKey() {
+		err := m.sshManager.GenerateKey(path, account.Email, opts)
+		return m.finishKeygen(keyGeneratedMsg{signing: signing, err: err}), nil
+	}
+
+	cmd, err := m.sshManager.KeygenCommand(path, account.Email, opts)
+	if err != nil {
+		return m.finishKeygen(keyGeneratedMsg{signing: signing, err: err}), nil
+	}
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err == nil {
+			err = m.sshManager.FinishKey(path)
+		}
+		return keyGeneratedMsg{signing: signing, err: err}
+	})
+}
+
+// finishKeygen records a new signing key and copies the new public key
+func (m model) finishKeygen(msg keyGeneratedMsg) model {
+	if msg.err != nil {
+		m.errorMsg = fmt.Sprintf("❌ Failed to generate key: %v", msg.err)
+		m.statusMsg = ""
+		return m.refreshDetails()
+	}
+
+	account, ok := m.selectedAccount()
+	if !ok {
+		return m
+	}
+
+	if !msg.signing {
+		pubKey, err := m.sshManager.GetPublicKey(account.SSHKeyPath)
+		if err != nil {
+			m.errorMsg = fmt.Sprintf("❌ Failed to read public key: %v", err)
+			m.statusMsg = ""
+			return m
+		}
+
+		if err := clipboard.WriteAll(pubKey); err != nil {
+			m.statusMsg = fmt.Sprintf("✓ Key generated for %s! Press 'enter' to see details", account.Name)
+		} else {
+			m.statusMsg = fmt.Sprintf("✓ SSH key for %s generated and copied! Add it to GitHub, then press 't' to test", account.Name)
+		}
+		m.errorMsg = ""
+		return m.refreshTable()
+	}
+
+	updated := account
+	updated.SigningKeyPath = account.DefaultSigningKeyPath()
+	if err := m.config.UpdateAccount(account.Name, updated); err != nil {
+		m.errorMsg = fmt.Sprintf("❌ Failed to save signing key: %v", err)
+		m.statusMsg = ""
+		return m.refreshDetails()
+	}
+	m = m.refreshTable()
+
+	m = m.copySigningKey()
+	if m.errorMsg == "" {
+		m.statusMsg = fmt.Sprintf("✓ Signing key for %s generated and copied! Add it on GitHub as a 'Signing Key', then press 'a' to apply", account.Name)
+	}
+	return m.refreshDetails()
+}
+
+// refreshDetails rebuilds the details view when it is showing
+func (m model) refreshDetails() model {
+	if m.mode == viewDetails {
+		return m.showDetails()
+	}
+	return m
+}
+
+func (m model) renderKeygen() string {
+	title := titleStyle.Render("Generate SSH Key")
+	if m.keygen.signing {
+		title = titleStyle.Render("Generate Signing Key")
+	}
+
+	path, _ := m.keygenPath(m.keygen.signing)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Key file: %s\n\nKey type:\n", path))
+	for i, opts := range ssh.KeyChoices {
+		line := "  " + opts.String()
+		if i == m.keygen.choice {
+			line = infoStyle.Render("▶ " + opts.String())
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if opts := ssh.KeyChoices[m.keygen.choice]; opts.SecurityKey() {
+		note := "\nInsert your security key; ssh-keygen will ask you to touch it"
+		if opts.Resident {
+			note += "\nand enter its PIN. Resident keys can be restored elsewhere with ssh-keygen -K"
+		}
+		note += "\nEvery push, pull and signature will then need a touch too"
+		b.WriteString(mutedStyle.Render(note))
+	}
+
+	help := helpStyle.Render("↑/↓:choose • enter:generate • esc:cancel")
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n", title, baseStyle.Render(b.String()), help)
+}
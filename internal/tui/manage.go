@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/secrets"
+)
+
+var modalStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("red")).
+	Padding(1, 2)
+
+// selectedAccount returns the account under the table cursor
+func (m model) selectedAccount() (config.Account, bool) {
+	cursor := m.table.Cursor()
+	if cursor < 0 || cursor >= len(m.visible) {
+		return config.Account{}, false
+	}
+	return m.visible[cursor], true
+}
+
+func (m model) startDeleteAccount() model {
+	account, ok := m.selectedAccount()
+	if !ok {
+		m.errorMsg = "❌ No account selected"
+		m.statusMsg = ""
+		return m
+	}
+
+	m.deletingName = account.Name
+	m.mode = viewConfirmDelete
+	return m
+}
+
+func (m model) handleConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	name := m.deletingName
+	m.deletingName = ""
+	m.mode = viewTable
+
+	switch msg.String() {
+	case "y", "Y":
+		if err := m.config.RemoveAccount(name); err != nil {
+			m.errorMsg = fmt.Sprintf("❌ Failed to delete account: %v", err)
+			m.statusMsg = ""
+			return m, nil
+		}
+
+		if err := m.gitManager.RemoveAccountGitconfig(name); err != nil {
+			m.errorMsg = fmt.Sprintf("❌ Deleted '%s' but %v", name, err)
+			m.statusMsg = ""
+			return m.refreshTable(), nil
+		}
+
+		m = m.refreshTable()
+		m.statusMsg = fmt.Sprintf("✓ Deleted account '%s'. Press 'a' to apply configs", name)
+		m.errorMsg = ""
+
+	default:
+		m.statusMsg = "Delete cancelled"
+		m.errorMsg = ""
+	}
+
+	return m, nil
+}
+
+func (m model) renderConfirmDelete() string {
+	title := titleStyle.Render("Delete Account")
+
+	body := fmt.Sprintf("Delete account %s?\n\n", infoStyle.Render(m.deletingName)) +
+		"This removes it from ghmm and deletes its per-account gitconfig.\n" +
+		"SSH keys are left in place.\n\n" +
+		"y: delete • n/esc: cancel"
+
+	return fmt.Sprintf("%s\n\n%s\n", title, modalStyle.Render(body))
+}
+
+func (m model) setDefaultAccount() model {
+	account, ok := m.selectedAccount()
+	if !ok {
+		m.errorMsg = "❌ No account selected"
+		m.statusMsg = ""
+		return m
+	}
+
+	if err := m.config.SetDefaultAccount(account.Name); err != nil {
+		m.errorMsg = fmt.Sprintf("❌ Failed to set default: %v", err)
+		m.statusMsg = ""
+		return m
+	}
+
+	m = m.refreshTable()
+	m.statusMsg = fmt.Sprintf("⭐ Default account set to '%s'. Press 'a' to apply configs", account.Name)
+	m.errorMsg = ""
+	return m
+}
+
+func (m model) startEditAccount() model {
+	account, ok := m.selectedAccount()
+	if !ok {
+		m.errorMsg = "❌ No account selected"
+		m.statusMsg = ""
+		return m
+	}
+
+	m = m.startAddAccount()
+	m.formInputs[0].SetValue(account.Name)
+	m.formInputs[1].SetValue(account.Username)
+	m.formInputs[2].SetValue(account.Email)
+	m.formInputs[3].SetValue(strings.Join(account.Directories, ", "))
+	m = m.setFormSigning(account.Signing)
+	m.editingName = account.Name
+	return m
+}
+
+func (m model) saveEditedAccount(name, username, email, directory string, signing config.Signing) (tea.Model, tea.Cmd) {
+	account, err := m.config.GetAccount(m.editingName)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("❌ %v", err)
+		return m, nil
+	}
+
+	updated := *account
+	updated.Name = name
+	updated.Username = username
+	updated.Email = email
+	updated.Directories = splitDirectories(directory)
+	updated.Signing = signing
+
+	// Keep the generated host alias in step with the account name
+	if name != account.Name && account.HostAlias == account.GitHubHost()+"-"+account.Name {
+		updated.HostAlias = account.GitHubHost() + "-" + name
+	}
+
+	if err := m.config.UpdateAccount(m.editingName, updated); err != nil {
+		return m.showFormErrors("Failed to update account", err), nil
+	}
+
+	// Carry the per-account gitconfig, and the user's own settings in it, over to the new name
+	var renameErr error
+	if name != m.editingName {
+		renameErr = m.gitManager.RenameAccountGitconfig(m.editingName, name)
+	}
+
+	m.mode = viewTable
+	m.formInputs = nil
+	m.formErrors = nil
+	m.editingName = ""
+	m = m.refreshTable()
+	m.statusMsg = fmt.Sprintf("✓ Updated account '%s'! Press 'a' to apply configs", name)
+	m.errorMsg = ""
+	if renameErr != nil {
+		m.errorMsg = fmt.Sprintf("❌ %v", renameErr)
+	}
+
+	if stored, _ := secrets.StoredAccounts(m.config.VaultFile()); name != account.Name && slices.Contains(stored, account.Name) {
+		move := &moveVaultToken{path: m.config.VaultFile(), from: account.Name, to: name}
+		if os.Getenv(secrets.PassphraseEnv) != "" {
+			return m.finishVaultMove(vaultTokenMovedMsg{from: move.from, to: move.to, err: move.Run()}), nil
+		}
+		return m, tea.Exec(move, func(err error) tea.Msg {
+			return vaultTokenMovedMsg{from: move.from, to: move.to, err: err}
+		})
+	}
+	return m, nil
+}
+
+// vaultTokenMovedMsg is sent when a renamed account's vault token has been moved
+type vaultTokenMovedMsg struct {
+	from, to string
+	err      error
+}
+
+// moveVaultToken moves a renamed account's token in the vault. Each token is
+// sealed with its account's name, so it has to be resealed with the
+// passphrase; the TUI steps aside while that is asked for on the terminal.
+type moveVaultToken struct {
+	path, from, to string
+	stdout         io.Writer
+}
+
+func (c *moveVaultToken) SetStdin(io.Reader)    {}
+func (c *moveVaultToken) SetStdout(w io.Writer) { c.stdout = w }
+func (c *moveVaultToken) SetStderr(io.Writer)   {}
+
+func (c *moveVaultToken) Run() error {
+	if c.stdout != nil {
+		fmt.Fprintf(c.stdout, "Moving the vault token of '%s' to '%s'\n", c.from, c.to)
+	}
+	passphrase, err := secrets.Passphrase(false)
+	if err != nil {
+		return err
+	}
+	vault, err := secrets.Open(c.path, passphrase)
+	if err != nil {
+		return err
+	}
+	return vault.Rename(c.from, c.to)
+}
+
+// finishVaultMove reports whether a renamed account's token followed it
+func (m model) finishVaultMove(msg vaultTokenMovedMsg) model {
+	if msg.err != nil {
+		m.errorMsg = fmt.Sprintf("❌ The token of '%s' is still in the vault under its old name: %v. Run 'ghmm-cli token set %s', then 'ghmm-cli token rm %s'",
+			msg.to, msg.err, msg.to, msg.from)
+		m.statusMsg = ""
+		return m
+	}
+	m.statusMsg = fmt.Sprintf("✓ Updated account '%s' and moved its vault token! Press 'a' to apply configs", msg.to)
+	return m
+}
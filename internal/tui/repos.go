@@ -0,0 +1,327 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/git"
+	"github.com/donbowman/github-multi-account-manager/internal/workspace"
+)
+
+// reposLoadedMsg carries the result of scanning an account directory
+type reposLoadedMsg struct {
+	account string
+	repos   []git.Repository
+	skipped []string // Directories that couldn't be read, e.g. on an unmounted disk
+}
+
+// editorFinishedMsg is sent when the external editor exits
+type editorFinishedMsg struct {
+	err error
+}
+
+// scanRepos finds and inspects the repositories under each of an account's
+// directories, skipping any that are missing so the others still show
+func scanRepos(account config.Account) tea.Cmd {
+	return func() tea.Msg {
+		msg := reposLoadedMsg{account: account.Name}
+		for _, dir := range account.Directories {
+			paths, err := workspace.FindRepositories(dir)
+			if err != nil {
+				msg.skipped = append(msg.skipped, dir)
+				continue
+			}
+			for _, path := range paths {
+				msg.repos = append(msg.repos, git.InspectRepository(path))
+			}
+		}
+		return msg
+	}
+}
+
+func (m model) startRepoBrowser() (model, tea.Cmd) {
+	account, ok := m.selectedAccount()
+	if !ok {
+		m.errorMsg = "❌ No account selected"
+		m.statusMsg = ""
+		return m, nil
+	}
+
+	m.repoAccount = account
+	m.repos = nil
+	m.reposLoading = true
+	m.repoTable = newRepoTable(m.width, m.height)
+	m.mode = viewRepos
+	m.statusMsg = fmt.Sprintf("Scanning %s...", strings.Join(account.Directories, ", "))
+	m.errorMsg = ""
+	return m, scanRepos(account)
+}
+
+func (m model) handleReposLoaded(msg reposLoadedMsg) model {
+	if msg.account != m.repoAccount.Name {
+		return m
+	}
+
+	m.reposLoading = false
+	m.repos = msg.repos
+	m = m.refreshRepoTable()
+	m.statusMsg = fmt.Sprintf("Found %d repositories", len(m.repos))
+	if len(msg.skipped) > 0 {
+		m.statusMsg += fmt.Sprintf(" (skipped %s: not found)", strings.Join(msg.skipped, ", "))
+	}
+	m.errorMsg = ""
+	return m
+}
+
+func (m model) handleReposInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.mode = viewTable
+		m.repos = nil
+		m.statusMsg = ""
+		m.errorMsg = ""
+		return m, nil
+
+	case "r":
+		m.reposLoading = true
+		m.statusMsg = "Rescanning..."
+		return m, scanRepos(m.repoAccount)
+
+	case "f":
+		return m.fixRepoRemote(), nil
+
+	case "e":
+		return m.openRepoInEditor()
+
+	case "c":
+		return m.copyRepoPath(), nil
+	}
+
+	var cmd tea.Cmd
+	m.repoTable, cmd = m.repoTable.Update(msg)
+	return m, cmd
+}
+
+// selectedRepo returns the repository under the repo table cursor
+func (m model) selectedRepo() (git.Repository, bool) {
+	cursor := m.repoTable.Cursor()
+	if cursor < 0 || cursor >= len(m.repos) {
+		return git.Repository{}, false
+	}
+	return m.repos[cursor], true
+}
+
+// usesAccountHost reports whether a repository's remote uses the account's SSH
+// host, either directly or through an insteadOf rewrite
+func usesAccountHost(repo git.Repository, account config.Account, rewrites []config.URLRewrite) bool {
+	remote, ok := workspace.ParseRemote(repo.RemoteURL)
+	if !ok {
+		return false
+	}
+	if remote.Host == account.SSHHost() {
+		return true
+	}
+	for _, rewrite := range rewrites {
+		if rewrite.Account.Name == account.Name && rewrite.Matches(repo.RemoteURL) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m model) fixRepoRemote() model {
+	repo, ok := m.selectedRepo()
+	if !ok {
+		return m
+	}
+
+	remote, ok := workspace.ParseRemote(repo.RemoteURL)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("❌ Can't parse remote %q", repo.RemoteURL)
+		m.statusMsg = ""
+		return m
+	}
+
+	// The owner's account wins over the directory the repository was found in
+	account := m.repoAccount
+	note := ""
+	route := m.config.RouteRepository(remote.Owner, repo.Path)
+	if len(route.Claimants) > 0 && route.Account != nil {
+		account = *route.Account
+		if account.Name != m.repoAccount.Name || len(route.Claimants) > 1 {
+			note = " (" + route.Reason + ")"
+		}
+	}
+
+	if remote.Host == account.SSHHost() {
+		m.statusMsg = "✓ Remote already uses " + account.SSHHost() + note
+		m.errorMsg = ""
+		return m
+	}
+	rewrites, _ := config.URLRewrites(m.config.ListAccounts())
+	if usesAccountHost(repo, account, rewrites) {
+		m.statusMsg = "✓ Remote is rewritten to " + account.HostAlias + " by url.insteadOf" + note
+		m.errorMsg = ""
+		return m
+	}
+
+	url := git.SSHCloneURL(account.SSHHost(), remote)
+	if err := git.SetRemoteURL(repo.Path, "origin", url); err != nil {
+		m.errorMsg = fmt.Sprintf("❌ %v", err)
+		m.statusMsg = ""
+		return m
+	}
+
+	m.repos[m.repoTable.Cursor()] = git.InspectRepository(repo.Path)
+	m = m.refreshRepoTable()
+	m.statusMsg = fmt.Sprintf("✓ origin now %s%s", url, note)
+	m.errorMsg = ""
+	return m
+}
+
+func (m model) openRepoInEditor() (tea.Model, tea.Cmd) {
+	repo, ok := m.selectedRepo()
+	if !ok {
+		return m, nil
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		m.errorMsg = "❌ $EDITOR is not set"
+		m.statusMsg = ""
+		return m, nil
+	}
+
+	// $EDITOR may include arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], repo.Path)...)
+	cmd.Dir = repo.Path
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	})
+}
+
+func (m model) copyRepoPath() model {
+	repo, ok := m.selectedRepo()
+	if !ok {
+		return m
+	}
+
+	if err := clipboard.WriteAll(repo.Path); err != nil {
+		m.errorMsg = "❌ Failed to copy to clipboard"
+		m.statusMsg = ""
+		return m
+	}
+
+	m.statusMsg = fmt.Sprintf("✓ Copied %s", repo.Path)
+	m.errorMsg = ""
+	return m
+}
+
+func newRepoTable(width, height int) table.Model {
+	repoWidth, remoteWidth := 30, 45
+	if width > 0 {
+		// Branch, alias and email columns are fixed, the rest is shared
+		flexible := max(width-20-8-30-12, 30)
+		repoWidth = flexible * 2 / 5
+		remoteWidth = flexible - repoWidth
+	}
+
+	t := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Repository", Width: repoWidth},
+			{Title: "Branch", Width: 20},
+			{Title: "Remote", Width: remoteWidth},
+			{Title: "Host", Width: 8},
+			{Title: "Commit Email", Width: 30},
+		}),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+	if height > 0 {
+		t.SetHeight(max(height-tableChrome, 3))
+	}
+	t.SetStyles(tableStyles())
+	return t
+}
+
+// repoDisplayName shows a repository relative to the account directory containing it,
+// prefixed with that directory's name when the account has several
+func repoDisplayName(account config.Account, path string) string {
+	for _, dir := range account.Directories {
+		name, err := filepath.Rel(dir, path)
+		if err != nil || strings.HasPrefix(name, "..") {
+			continue
+		}
+		if name == "." {
+			name = filepath.Base(path)
+		}
+		if len(account.Directories) > 1 {
+			name = filepath.Join(filepath.Base(dir), name)
+		}
+		return name
+	}
+	return filepath.Base(path)
+}
+
+func (m model) refreshRepoTable() model {
+	rows := make([]table.Row, 0, len(m.repos))
+	rewrites, _ := config.URLRewrites(m.config.ListAccounts())
+	for _, repo := range m.repos {
+		name := repoDisplayName(m.repoAccount, repo.Path)
+
+		alias := "✗"
+		if usesAccountHost(repo, m.repoAccount, rewrites) {
+			alias = "✓"
+		}
+
+		email := repo.CommitEmail
+		if email == "" {
+			email = "(unset)"
+		} else if email != m.repoAccount.Email {
+			email = "⚠ " + email
+		}
+
+		rows = append(rows, table.Row{name, repo.Branch, repo.RemoteURL, alias, email})
+	}
+
+	m.repoTable.SetRows(rows)
+	return m
+}
+
+func (m model) renderRepos() string {
+	title := titleStyle.Render(fmt.Sprintf("Repositories for %s", m.repoAccount.Name))
+	subtitle := mutedStyle.Render(fmt.Sprintf("%s • expected host %s • expected email %s",
+		strings.Join(m.repoAccount.Directories, ", "), m.repoAccount.SSHHost(), m.repoAccount.Email))
+
+	body := m.repoTable.View()
+	if m.reposLoading && len(m.repos) == 0 {
+		body = "Scanning for repositories..."
+	} else if len(m.repos) == 0 {
+		body = "No git repositories found"
+	}
+
+	var status string
+	if m.errorMsg != "" {
+		status = errorStyle.Render(m.errorMsg)
+	} else if m.statusMsg != "" {
+		status = statusStyle.Render(m.statusMsg)
+	}
+
+	help := helpStyle.Render("f:fix remote • e:open in $EDITOR • c:copy path • r:rescan • esc:back")
+
+	return fmt.Sprintf("%s\n%s\n\n%s\n\n%s\n%s\n",
+		title,
+		subtitle,
+		baseStyle.Render(body),
+		status,
+		help,
+	)
+}
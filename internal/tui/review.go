@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/donbowman/github-multi-account-manager/internal/config"
+)
+
+// reviewState holds the detection report while the user accepts, edits or rejects candidates
+type reviewState struct {
+	candidates []config.Candidate
+	accepted   []bool
+	unpaired   map[string]string
+	cursor     int
+	editing    bool // The add/edit form is open for the candidate under the cursor
+}
+
+func (m model) autoSync() model {
+	report, err := m.config.DetectionReport()
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("❌ Auto-sync failed: %v", err)
+		m.statusMsg = ""
+		return m
+	}
+
+	if len(report.Candidates) == 0 {
+		m.statusMsg = "No accounts found in .gitconfig or .ssh/config"
+		m.errorMsg = ""
+		return m
+	}
+
+	return m.startReview(report)
+}
+
+func (m model) startReview(report *config.DetectionReport) model {
+	accepted := make([]bool, len(report.Candidates))
+	for i, candidate := range report.Candidates {
+		accepted[i] = !candidate.Exists && !candidate.ReviewOnly()
+	}
+
+	m.review = reviewState{
+		candidates: report.Candidates,
+		accepted:   accepted,
+		unpaired:   report.UnpairedAliases,
+	}
+	m.mode = viewReview
+	m.statusMsg = ""
+	m.errorMsg = ""
+	return m
+}
+
+func (m model) handleReviewInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := &m.review
+
+	switch msg.String() {
+	case "esc", "q":
+		m.mode = viewTable
+		m.review = reviewState{}
+		m.statusMsg = "Import cancelled, nothing was saved"
+		m.errorMsg = ""
+
+	case "up", "k":
+		if r.cursor > 0 {
+			r.cursor--
+		}
+
+	case "down", "j":
+		if r.cursor < len(r.candidates)-1 {
+			r.cursor++
+		}
+
+	case " ", "a":
+		if r.candidates[r.cursor].Exists {
+			m.errorMsg = fmt.Sprintf("❌ '%s' is already configured", r.candidates[r.cursor].Account.Name)
+			break
+		}
+		r.accepted[r.cursor] = !r.accepted[r.cursor]
+		m.errorMsg = ""
+
+	case "x":
+		r.accepted[r.cursor] = false
+
+	case "e":
+		return m.startEditCandidate(), nil
+
+	case "enter":
+		return m.importReviewed(), nil
+	}
+
+	return m, nil
+}
+
+func (m model) startEditCandidate() model {
+	candidate := m.review.candidates[m.review.cursor]
+
+	m = m.startAddAccount()
+	m.formInputs[0].SetValue(candidate.Account.Name)
+	m.formInputs[1].SetValue(candidate.Account.Username)
+	m.formInputs[2].SetValue(candidate.Account.Email)
+	m.formInputs[3].SetValue(strings.Join(candidate.Account.Directories, ", "))
+	m = m.setFormSigning(candidate.Account.Signing)
+	m.review.editing = true
+	return m
+}
+
+// saveEditedCandidate stores the form values on the candidate and returns to the review
+func (m model) saveEditedCandidate(name, username, email, directory string, signing config.Signing) (tea.Model, tea.Cmd) {
+	r := &m.review
+	candidate := &r.candidates[r.cursor]
+
+	edited := candidate.Account
+	edited.Name = name
+	edited.Username = username
+	edited.Email = email
+	edited.Directories = splitDirectories(directory)
+	edited.Signing = signing
+
+	// Only the fields on the form are checked here; import fills in the rest
+	var invalid config.ValidationErrors
+	if err := edited.Validate(); err != nil {
+		for _, e := range err.(config.ValidationErrors) {
+			if slices.Contains(formFields, e.Field) {
+				invalid = append(invalid, e)
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		return m.showFormErrors("Invalid account", invalid), nil
+	}
+	candidate.Account = edited
+
+	_, err := m.config.GetAccount(name)
+	candidate.Exists = err == nil
+	r.accepted[r.cursor] = !candidate.Exists
+
+	r.editing = false
+	m.formInputs = nil
+	m.formErrors = nil
+	m.mode = viewReview
+	m.errorMsg = ""
+	return m, nil
+}
+
+func (m model) importReviewed() model {
+	var accounts []config.Account
+	for i, candidate := range m.review.candidates {
+		if m.review.accepted[i] {
+			accounts = append(accounts, candidate.Account)
+		}
+	}
+
+	imported, err := m.config.ImportAccounts(accounts)
+	var invalid config.ValidationErrors
+	if err != nil && !errors.As(err, &invalid) {
+		m.errorMsg = fmt.Sprintf("❌ Import failed: %v", err)
+		m.statusMsg = ""
+		return m
+	}
+
+	m.mode = viewTable
+	m.review = reviewState{}
+	m = m.refreshTable()
+	if imported > 0 {
+		m.emptyStartup = false
+		m.statusMsg = fmt.Sprintf("✓ Imported %d account(s) from existing setup! Press 'a' to apply configs", imported)
+	} else {
+		m.statusMsg = "No accounts imported"
+	}
+	m.errorMsg = ""
+	if len(invalid) > 0 {
+		m.errorMsg = fmt.Sprintf("❌ Some accounts were skipped: %v", invalid[0])
+	}
+	return m
+}
+
+func (m model) renderReview() string {
+	title := titleStyle.Render("Review Detected Accounts")
+
+	var b strings.Builder
+	for i, candidate := range m.review.candidates {
+		acc := candidate.Account
+
+		cursor := "  "
+		if i == m.review.cursor {
+			cursor = "▸ "
+		}
+		box := "[ ]"
+		if m.review.accepted[i] {
+			box = "[x]"
+		}
+
+		line := fmt.Sprintf("%s%s %s  %s  <%s>  %s", cursor, box, acc.Name, acc.Username, acc.Email, strings.Join(acc.Directories, ", "))
+		if acc.Host != "" {
+			line += "  @" + acc.Host
+		}
+		if i == m.review.cursor {
+			line = infoStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+
+		b.WriteString(mutedStyle.Render("      found via " + candidate.Source))
+		b.WriteString("\n")
+		if acc.Username == "" && !candidate.Exists {
+			b.WriteString(errorStyle.Render("      username not detected; press 'e' to enter it") + "\n")
+		}
+		if acc.Email == "" && !candidate.Exists {
+			b.WriteString(errorStyle.Render("      email not detected; press 'e' to enter it") + "\n")
+		}
+
+		switch {
+		case candidate.Exists:
+			b.WriteString(mutedStyle.Render("      already configured, will be skipped"))
+		case candidate.Rule == "":
+			b.WriteString(errorStyle.Render("      no SSH alias matched"))
+			b.WriteString(mutedStyle.Render("; a new key and alias will be set up"))
+		default:
+			pairing := fmt.Sprintf("      paired with %s (%s): %s, %d%% confidence",
+				acc.HostAlias, acc.SSHKeyPath, candidate.Rule, candidate.Confidence)
+			if candidate.Confidence >= 80 {
+				b.WriteString(statusStyle.Render(pairing))
+			} else {
+				b.WriteString(pairing)
+			}
+		}
+		b.WriteString("\n\n")
+	}
+
+	if len(m.review.unpaired) > 0 {
+		aliases := make([]string, 0, len(m.review.unpaired))
+		for alias := range m.review.unpaired {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		b.WriteString(mutedStyle.Render("Unpaired SSH aliases: " + strings.Join(aliases, ", ")))
+		b.WriteString("\n")
+	}
+
+	var status string
+	if m.errorMsg != "" {
+		status = errorStyle.Render(m.errorMsg)
+	}
+
+	help := helpStyle.Render("↑/↓:move • space:accept/skip • x:reject • e:edit • enter:import accepted • esc:cancel")
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n%s\n",
+		title,
+		baseStyle.Render(b.String()),
+		status,
+		help,
+	)
+}
@@ -0,0 +1,188 @@
+package tui
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/donbowman/github-multi-account-manager/internal/config"
+	"github.com/donbowman/github-multi-account-manager/internal/ssh"
+)
+
+// sortColumn identifies the table column accounts are ordered by
+type sortColumn int
+
+const (
+	sortNone sortColumn = iota
+	sortName
+	sortUsername
+	sortEmail
+	sortDirectory
+	sortStatus
+)
+
+// columnTitles holds the table headers, indexed by sortColumn-1
+var columnTitles = []string{"Name", "Username", "Email", "Directory", "Status"}
+
+// columnWeights sizes the flexible columns relative to each other
+var columnWeights = []int{20, 20, 35, 30}
+
+const (
+	statusColumnWidth = 12
+	minColumnWidth    = 8
+	// Lines taken by the title, table border and header, status and help
+	tableChrome = 11
+)
+
+// accountStatus describes whether an account's SSH key is present
+func accountStatus(acc config.Account) string {
+	if _, err := os.Stat(acc.SSHKeyPath); err == nil {
+		if ssh.IsSecurityKey(acc.SSHKeyPath) {
+			return "🔐 Ready"
+		}
+		return "✅ Ready"
+	}
+	return "⚠️ No key"
+}
+
+// matchesFilter reports whether any visible field contains the filter text
+func matchesFilter(acc config.Account, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	for _, field := range []string{acc.Name, acc.Username, acc.Email, strings.Join(acc.Directories, " ")} {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortKey returns the value an account is ordered by for a column
+func sortKey(acc config.Account, column sortColumn) string {
+	switch column {
+	case sortName:
+		return strings.ToLower(acc.Name)
+	case sortUsername:
+		return strings.ToLower(acc.Username)
+	case sortEmail:
+		return strings.ToLower(acc.Email)
+	case sortDirectory:
+		return strings.ToLower(acc.PrimaryDirectory())
+	case sortStatus:
+		return accountStatus(acc)
+	default:
+		return ""
+	}
+}
+
+// visibleAccounts returns the accounts matching the filter in display order
+func (m model) visibleAccounts() []config.Account {
+	var visible []config.Account
+	for _, acc := range m.config.ListAccounts() {
+		if matchesFilter(acc, m.filterInput.Value()) {
+			visible = append(visible, acc)
+		}
+	}
+
+	if m.sortBy != sortNone {
+		sort.SliceStable(visible, func(i, j int) bool {
+			a, b := sortKey(visible[i], m.sortBy), sortKey(visible[j], m.sortBy)
+			if m.sortDesc {
+				return a > b
+			}
+			return a < b
+		})
+	}
+
+	return visible
+}
+
+// columns builds the table columns for the current width and sort order
+func (m model) columns() []table.Column {
+	widths := []int{20, 20, 35, 30}
+	if m.width > 0 {
+		// Each column has one cell of padding on either side, plus the border
+		available := m.width - statusColumnWidth - 2*len(columnTitles) - 2
+		total := 0
+		for _, w := range columnWeights {
+			total += w
+		}
+		for i, w := range columnWeights {
+			widths[i] = max(available*w/total, minColumnWidth)
+		}
+	}
+	widths = append(widths, statusColumnWidth)
+
+	columns := make([]table.Column, len(columnTitles))
+	for i, title := range columnTitles {
+		if sortColumn(i+1) == m.sortBy {
+			if m.sortDesc {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		columns[i] = table.Column{Title: title, Width: widths[i]}
+	}
+	return columns
+}
+
+// resizeTable fits the table columns and height to the terminal
+func (m model) resizeTable() model {
+	m.table.SetColumns(m.columns())
+	if m.height > 0 {
+		m.table.SetHeight(max(m.height-tableChrome, 3))
+	}
+	return m
+}
+
+func (m model) startFilter() (model, tea.Cmd) {
+	m.filtering = true
+	return m, m.filterInput.Focus()
+}
+
+func (m model) handleFilterInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filtering = false
+		m.filterInput.Blur()
+		m.filterInput.SetValue("")
+		return m.refreshTable(), nil
+
+	case "enter":
+		m.filtering = false
+		m.filterInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	m = m.refreshTable()
+	m.table.SetCursor(0)
+	return m, cmd
+}
+
+// cycleSort moves the sort to the next column, wrapping back to config order
+func (m model) cycleSort() model {
+	m.sortBy = (m.sortBy + 1) % (sortStatus + 1)
+	return m.resizeTable().refreshTable()
+}
+
+func (m model) toggleSortDirection() model {
+	m.sortDesc = !m.sortDesc
+	return m.resizeTable().refreshTable()
+}
+
+func newFilterInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "filter by name, username, email or directory"
+	input.CharLimit = 100
+	input.Width = 50
+	return input
+}
@@ -1,14 +1,17 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/donbowman/github-multi-account-manager/internal/config"
@@ -55,6 +58,14 @@ type keyMap struct {
 	AutoSync    key.Binding
 	GenerateKey key.Binding
 	TestConn    key.Binding
+	Delete      key.Binding
+	SetDefault  key.Binding
+	Edit        key.Binding
+	Filter      key.Binding
+	Sort        key.Binding
+	SortReverse key.Binding
+	Repos       key.Binding
+	Clone       key.Binding
 }
 
 var keys = keyMap{
@@ -76,7 +87,7 @@ var keys = keyMap{
 	),
 	Apply: key.NewBinding(
 		key.WithKeys("a"),
-		key.WithHelp("a", "apply configs"),
+		key.WithHelp("a", "review & apply configs"),
 	),
 	CopyKey: key.NewBinding(
 		key.WithKeys("c"),
@@ -102,6 +113,38 @@ var keys = keyMap{
 		key.WithKeys("t"),
 		key.WithHelp("t", "test connection"),
 	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete account"),
+	),
+	SetDefault: key.NewBinding(
+		key.WithKeys("D", "*"),
+		key.WithHelp("D/*", "set default"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit account"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "cycle sort column"),
+	),
+	SortReverse: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "reverse sort"),
+	),
+	Repos: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "browse repositories"),
+	),
+	Clone: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "clone repository"),
+	),
 }
 
 type viewMode int
@@ -110,10 +153,17 @@ const (
 	viewTable viewMode = iota
 	viewAddAccount
 	viewDetails
+	viewApplyPreview
+	viewConfirmDelete
+	viewRepos
+	viewClone
+	viewReview
+	viewKeygen
 )
 
 type model struct {
 	table        table.Model
+	visible      []config.Account // Accounts backing the table rows, in row order
 	config       *config.Config
 	sshManager   *ssh.Manager
 	gitManager   *git.Manager
@@ -122,10 +172,36 @@ type model struct {
 	errorMsg     string
 	mode         viewMode
 	detailsText  string
-	// Form fields for adding account
+	// Form fields for adding or editing an account
 	formInputs   []textinput.Model
 	formFocused  int
-	emptyStartup bool // True if started with no accounts
+	formErrors   config.ValidationErrors // Shown inline under the offending input
+	editingName  string                  // Name of the account being edited, empty when adding
+	deletingName string                  // Name of the account awaiting delete confirmation
+	emptyStartup bool                    // True if started with no accounts
+	// Table filtering and sorting
+	filterInput textinput.Model
+	filtering   bool
+	sortBy      sortColumn
+	sortDesc    bool
+	// Apply preview state
+	preview      viewport.Model
+	applyChanges []fileChange
+	applyTargets [len(applyTargetNames)]bool
+	// Repository browser state
+	repoAccount  config.Account
+	repos        []git.Repository
+	repoTable    table.Model
+	reposLoading bool
+	// Clone dialog state
+	clone cloneState
+	// Detection review state
+	review reviewState
+	// Key type chooser state
+	keygen keygenState
+	// Terminal size, zero until the first WindowSizeMsg
+	width  int
+	height int
 }
 
 func (m model) Init() tea.Cmd {
@@ -136,12 +212,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.preview.Width = m.previewWidth()
+		m.preview.Height = m.previewHeight()
+		m = m.resizeTable()
+
+	case reposLoadedMsg:
+		return m.handleReposLoaded(msg), nil
+
+	case cloneOutputMsg:
+		return m.handleCloneOutput(msg)
+
+	case cloneDoneMsg:
+		return m.handleCloneDone(msg)
+
+	case keyGeneratedMsg:
+		return m.finishKeygen(msg), nil
+
+	case vaultTokenMovedMsg:
+		return m.finishVaultMove(msg), nil
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("❌ Editor failed: %v", msg.err)
+			m.statusMsg = ""
+		}
+		return m, nil
+
 	case tea.KeyMsg:
-		// If showing details, any key dismisses
 		if m.mode == viewDetails {
-			m.mode = viewTable
-			m.detailsText = ""
-			return m, nil
+			return m.handleDetailsInput(msg)
 		}
 
 		// If in add account mode, handle form navigation
@@ -149,6 +251,34 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleFormInput(msg)
 		}
 
+		if m.mode == viewApplyPreview {
+			return m.handleApplyPreviewInput(msg)
+		}
+
+		if m.mode == viewConfirmDelete {
+			return m.handleConfirmDelete(msg)
+		}
+
+		if m.mode == viewRepos {
+			return m.handleReposInput(msg)
+		}
+
+		if m.mode == viewClone {
+			return m.handleCloneInput(msg)
+		}
+
+		if m.mode == viewReview {
+			return m.handleReviewInput(msg)
+		}
+
+		if m.mode == viewKeygen {
+			return m.handleKeygenInput(msg)
+		}
+
+		if m.filtering {
+			return m.handleFilterInput(msg)
+		}
+
 		switch {
 		case key.Matches(msg, keys.Quit):
 			return m, tea.Quit
@@ -159,13 +289,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.errorMsg = ""
 
 		case key.Matches(msg, keys.Apply):
-			m = m.applyConfigs()
+			m = m.startApplyPreview()
 
 		case key.Matches(msg, keys.CopyKey):
 			m = m.copySSHKey()
 
+		// Keys the table also binds (space pages down, g jumps to the top, d
+		// half-pages down) must not reach it, or the cursor moves off the
+		// account the action was started for
 		case key.Matches(msg, keys.ShowDetails):
-			m = m.showDetails()
+			return m.showDetails(), nil
 
 		case key.Matches(msg, keys.AddAccount):
 			m = m.startAddAccount()
@@ -174,10 +307,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m = m.autoSync()
 
 		case key.Matches(msg, keys.GenerateKey):
-			m = m.generateSSHKey()
+			return m.startKeygen(false), nil
 
 		case key.Matches(msg, keys.TestConn):
 			m = m.testConnection()
+
+		case key.Matches(msg, keys.Delete):
+			return m.startDeleteAccount(), nil
+
+		case key.Matches(msg, keys.SetDefault):
+			m = m.setDefaultAccount()
+
+		case key.Matches(msg, keys.Edit):
+			m = m.startEditAccount()
+
+		case key.Matches(msg, keys.Filter):
+			return m.startFilter()
+
+		case key.Matches(msg, keys.Sort):
+			m = m.cycleSort()
+
+		case key.Matches(msg, keys.SortReverse):
+			m = m.toggleSortDirection()
+
+		case key.Matches(msg, keys.Repos):
+			return m.startRepoBrowser()
+
+		case key.Matches(msg, keys.Clone):
+			m = m.startClone()
+
+		case msg.String() == "esc" && m.filterInput.Value() != "":
+			m.filterInput.SetValue("")
+			m = m.refreshTable()
 		}
 	}
 
@@ -191,6 +352,18 @@ func (m model) View() string {
 		return m.renderDetails()
 	case viewAddAccount:
 		return m.renderAddAccountForm()
+	case viewApplyPreview:
+		return m.renderApplyPreview()
+	case viewConfirmDelete:
+		return m.renderConfirmDelete()
+	case viewRepos:
+		return m.renderRepos()
+	case viewClone:
+		return m.renderClone()
+	case viewReview:
+		return m.renderReview()
+	case viewKeygen:
+		return m.renderKeygen()
 	default:
 		return m.renderTable()
 	}
@@ -207,11 +380,20 @@ func (m model) renderTable() string {
 	}
 
 	help := helpStyle.Render(
-		"q:quit • n:add • s:sync • g:gen key • t:test • a:apply • c:copy • enter:details",
+		"q:quit • n:add • e:edit • d:delete • D:default • s:sync • g:gen key • t:test • a:apply • c:copy • b:repos • C:clone • enter:details • /:filter • o/O:sort",
 	)
 
-	return fmt.Sprintf("%s\n\n%s\n\n%s\n%s\n",
+	filter := ""
+	if m.filtering || m.filterInput.Value() != "" {
+		filter = fmt.Sprintf("%s  %s",
+			m.filterInput.View(),
+			mutedStyle.Render(fmt.Sprintf("%d of %d", len(m.visible), len(m.config.ListAccounts()))),
+		)
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s\n\n%s\n%s\n",
 		title,
+		filter,
 		baseStyle.Render(m.table.View()),
 		status,
 		help,
@@ -221,93 +403,129 @@ func (m model) renderTable() string {
 func (m model) renderDetails() string {
 	title := titleStyle.Render("Account Details")
 	content := baseStyle.Render(m.detailsText)
-	help := helpStyle.Render("Press any key to return")
 
-	return fmt.Sprintf("%s\n\n%s\n\n%s\n", title, content, help)
+	var status string
+	if m.errorMsg != "" {
+		status = errorStyle.Render(m.errorMsg)
+	} else if m.statusMsg != "" {
+		status = statusStyle.Render(m.statusMsg)
+	}
+
+	help := helpStyle.Render("c:copy auth key • s:copy signing key • g:gen signing key • any other key to return")
+
+	return fmt.Sprintf("%s\n\n%s\n%s\n\n%s\n", title, content, status, help)
+}
+
+// handleDetailsInput copies either key or generates a signing key; any other key returns to the table
+func (m model) handleDetailsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "c":
+		m = m.copySSHKey()
+	case "s":
+		m = m.copySigningKey()
+	case "g":
+		m = m.startKeygen(true)
+		if m.mode == viewKeygen {
+			return m, nil
+		}
+	default:
+		m.mode = viewTable
+		m.detailsText = ""
+		m.statusMsg = ""
+		m.errorMsg = ""
+		return m, nil
+	}
+	return m.showDetails(), nil
 }
 
 func (m model) refreshTable() model {
-	accounts := m.config.ListAccounts()
+	m.visible = m.visibleAccounts()
 	defaultAcc := m.config.GetDefaultAccount()
 
 	rows := []table.Row{}
-	for _, acc := range accounts {
+	for _, acc := range m.visible {
 		name := acc.Name
 		if name == defaultAcc {
 			name = "⭐ " + name
 		}
 
-		// Check if SSH key exists
-		status := "⚠️ No key"
-		if _, err := os.Stat(acc.SSHKeyPath); err == nil {
-			status = "✅ Ready"
-		}
-
 		rows = append(rows, table.Row{
 			name,
 			acc.Username,
 			acc.Email,
-			acc.Directory,
-			status,
+			strings.Join(acc.Directories, ", "),
+			accountStatus(acc),
 		})
 	}
 
 	m.table.SetRows(rows)
+	if m.table.Cursor() >= len(rows) {
+		m.table.SetCursor(max(len(rows)-1, 0))
+	}
 	return m
 }
 
+// applyConfigs writes the configs for every target enabled in the apply preview
 func (m model) applyConfigs() model {
 	accounts := m.config.ListAccounts()
 	defaultAcc := m.config.GetDefaultAccount()
 
-	// Update SSH config
-	if err := m.sshManager.UpdateSSHConfig(accounts); err != nil {
-		m.errorMsg = fmt.Sprintf("❌ SSH config failed: %v", err)
-		m.statusMsg = ""
-		return m
+	if m.applyTargets[targetSSH] {
+		// Update SSH config
+		if err := m.sshManager.UpdateSSHConfig(accounts); err != nil {
+			m.errorMsg = fmt.Sprintf("❌ SSH config failed: %v", err)
+			m.statusMsg = ""
+			return m
+		}
 	}
 
-	// Update Git config
-	if err := m.gitManager.UpdateGitconfig(accounts); err != nil {
-		m.errorMsg = fmt.Sprintf("❌ Git config failed: %v", err)
-		m.statusMsg = ""
-		return m
-	}
+	if m.applyTargets[targetGit] {
+		// Update Git config
+		if err := m.gitManager.UpdateGitconfig(accounts, m.config.AllowedSignersFile()); err != nil {
+			m.errorMsg = fmt.Sprintf("❌ Git config failed: %v", err)
+			m.statusMsg = ""
+			return m
+		}
 
-	// Create individual gitconfigs
-	for _, acc := range accounts {
-		if err := m.gitManager.CreateAccountGitconfig(acc); err != nil {
-			m.errorMsg = fmt.Sprintf("❌ Failed to create gitconfig for %s: %v", acc.Name, err)
+		if err := m.gitManager.UpdateAllowedSigners(m.config.AllowedSignersFile(), accounts, m.config.SignerFiles); err != nil {
+			m.errorMsg = fmt.Sprintf("❌ %v", err)
 			m.statusMsg = ""
 			return m
 		}
+
+		// Create individual gitconfigs
+		for _, acc := range accounts {
+			if err := m.gitManager.CreateAccountGitconfig(acc); err != nil {
+				m.errorMsg = fmt.Sprintf("❌ Failed to create gitconfig for %s: %v", acc.Name, err)
+				m.statusMsg = ""
+				return m
+			}
+		}
 	}
 
-	// Update shell config
-	if err := m.shellManager.UpdateShellConfig(accounts, defaultAcc); err != nil {
-		m.errorMsg = fmt.Sprintf("❌ Shell config failed: %v", err)
-		m.statusMsg = ""
-		return m
+	if m.applyTargets[targetShell] {
+		// Update shell config
+		if err := m.shellManager.UpdateShellConfig(accounts, defaultAcc); err != nil {
+			m.errorMsg = fmt.Sprintf("❌ Shell config failed: %v", err)
+			m.statusMsg = ""
+			return m
+		}
+		m.statusMsg = fmt.Sprintf("✓ Configs applied! Reload shell: %s", m.shellManager.GetReloadCommand())
+	} else {
+		m.statusMsg = "✓ Configs applied!"
 	}
 
-	m.statusMsg = fmt.Sprintf("✓ Configs applied! Reload shell: %s", m.shellManager.GetReloadCommand())
 	m.errorMsg = ""
 	return m
 }
 
 func (m model) copySSHKey() model {
-	if m.table.Cursor() < 0 {
+	account, ok := m.selectedAccount()
+	if !ok {
 		m.errorMsg = "❌ No account selected"
 		m.statusMsg = ""
 		return m
 	}
-
-	accounts := m.config.ListAccounts()
-	if m.table.Cursor() >= len(accounts) {
-		return m
-	}
-
-	account := accounts[m.table.Cursor()]
 	pubKey, err := m.sshManager.GetPublicKey(account.SSHKeyPath)
 	if err != nil {
 		m.errorMsg = fmt.Sprintf("❌ No SSH key found for %s", account.Name)
@@ -347,42 +565,85 @@ func wrapText(text string, width int) string {
 }
 
 func (m model) showDetails() model {
-	if m.table.Cursor() < 0 {
+	account, ok := m.selectedAccount()
+	if !ok {
 		return m
 	}
 
-	accounts := m.config.ListAccounts()
-	if m.table.Cursor() >= len(accounts) {
-		return m
-	}
-
-	account := accounts[m.table.Cursor()]
-
 	var details strings.Builder
 	details.WriteString(fmt.Sprintf("Account: %s\n\n", infoStyle.Render(account.Name)))
 	details.WriteString(fmt.Sprintf("Username:     %s\n", account.Username))
 	details.WriteString(fmt.Sprintf("Email:        %s\n", account.Email))
-	details.WriteString(fmt.Sprintf("Directory:    %s\n", account.Directory))
+	for i, dir := range account.Directories {
+		label := "Directories:"
+		if i > 0 {
+			label = ""
+		}
+		details.WriteString(fmt.Sprintf("%-13s %s\n", label, dir))
+	}
+	for i, pattern := range account.RemotePatterns {
+		label := "Remotes:"
+		if i > 0 {
+			label = ""
+		}
+		details.WriteString(fmt.Sprintf("%-13s %s\n", label, pattern))
+	}
+	if len(account.Orgs) > 0 {
+		orgs := strings.Join(account.Orgs, ", ")
+		if account.RewriteURLs {
+			orgs += " (URLs rewritten to " + account.HostAlias + ")"
+		}
+		details.WriteString(fmt.Sprintf("Orgs:         %s\n", orgs))
+	}
 	details.WriteString(fmt.Sprintf("SSH Key:      %s\n", account.SSHKeyPath))
-	details.WriteString(fmt.Sprintf("Host Alias:   %s\n\n", account.HostAlias))
+	if account.HasSeparateSigningKey() {
+		details.WriteString(fmt.Sprintf("Signing Key:  %s\n", account.SigningKeyPath))
+	}
+	details.WriteString(fmt.Sprintf("SSH Mode:     %s\n", account.SSHModeDescription()))
+	details.WriteString(fmt.Sprintf("Signing:      %s\n\n", account.SigningDescription()))
 
 	// Check SSH key status
 	if _, err := os.Stat(account.SSHKeyPath); err == nil {
 		pubKey, err := m.sshManager.GetPublicKey(account.SSHKeyPath)
 		if err == nil {
-			details.WriteString(statusStyle.Render("✓ SSH Key exists\n\n"))
-			details.WriteString("Public Key:\n")
+			details.WriteString(statusStyle.Render("✓ SSH Key exists\n"))
+			if keyType := ssh.PublicKeyType(account.SSHKeyPath); ssh.IsSecurityKey(account.SSHKeyPath) {
+				details.WriteString(infoStyle.Render("👆 Security key ("+keyType+"): touch it whenever git connects") + "\n")
+			}
+			details.WriteString("\n")
+			details.WriteString("Authentication Key (add on GitHub with Key type 'Authentication Key'):\n")
 
 			// Wrap the SSH key to prevent overflow
 			// SSH keys are typically 300-400 chars, wrap at 80 chars per line
 			wrapped := wrapText(pubKey, 80)
 			details.WriteString(infoStyle.Render(wrapped))
-			details.WriteString("\n\nPress 'c' in main view to copy to clipboard")
+			details.WriteString("\n\nPress 'c' to copy to clipboard")
 		}
 	} else {
 		details.WriteString(errorStyle.Render("⚠ No SSH key found\n"))
 		details.WriteString("Generate with: ssh-keygen -t ed25519 -C \"" + account.Email + "\" -f " + account.SSHKeyPath)
 	}
+	details.WriteString("\n\n")
+
+	// Check signing key status
+	switch {
+	case account.HasSeparateSigningKey():
+		pubKey, err := m.sshManager.GetPublicKey(account.SigningKeyPath)
+		if err != nil {
+			details.WriteString(errorStyle.Render("⚠ No signing key found\n"))
+			details.WriteString("Press 'g' to generate it at " + account.SigningKeyPath)
+			break
+		}
+		if keyType := ssh.PublicKeyType(account.SigningKeyPath); ssh.IsSecurityKey(account.SigningKeyPath) {
+			details.WriteString(infoStyle.Render("👆 Security key ("+keyType+"): touch it whenever git signs") + "\n")
+		}
+		details.WriteString("Signing Key (add on GitHub with Key type 'Signing Key'):\n")
+		details.WriteString(infoStyle.Render(wrapText(pubKey, 80)))
+		details.WriteString("\n\nPress 's' to copy to clipboard")
+	case account.SigningMode() == config.SigningSSH && account.Signing.Key == "":
+		details.WriteString(mutedStyle.Render("Commits are signed with the authentication key; add it on GitHub a second time\n" +
+			"with Key type 'Signing Key', or press 'g' to generate a separate signing key"))
+	}
 
 	m.detailsText = details.String()
 	m.mode = viewDetails
@@ -391,7 +652,7 @@ func (m model) showDetails() model {
 
 func (m model) startAddAccount() model {
 	// Initialize form inputs
-	inputs := make([]textinput.Model, 4)
+	inputs := make([]textinput.Model, len(formFields))
 
 	inputs[0] = textinput.New()
 	inputs[0].Placeholder = "work, personal, etc."
@@ -413,13 +674,33 @@ func (m model) startAddAccount() model {
 	inputs[2].Prompt = "Email: "
 
 	inputs[3] = textinput.New()
-	inputs[3].Placeholder = "~/code/work"
+	inputs[3].Placeholder = "~/code/work, ~/clients/acme"
 	inputs[3].CharLimit = 200
 	inputs[3].Width = 40
-	inputs[3].Prompt = "Directory: "
+	inputs[3].Prompt = "Directories: "
+
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "ssh (default), gpg, x509 or none"
+	inputs[4].CharLimit = 10
+	inputs[4].Width = 40
+	inputs[4].Prompt = "Signing: "
+
+	inputs[5] = textinput.New()
+	inputs[5].Placeholder = "the SSH key, or a GPG/X.509 key id"
+	inputs[5].CharLimit = 200
+	inputs[5].Width = 40
+	inputs[5].Prompt = "Signing key: "
+
+	inputs[6] = textinput.New()
+	inputs[6].Placeholder = "commits, tags"
+	inputs[6].CharLimit = 50
+	inputs[6].Width = 40
+	inputs[6].Prompt = "Always sign: "
 
 	m.formInputs = inputs
 	m.formFocused = 0
+	m.formErrors = nil
+	m.editingName = ""
 	m.mode = viewAddAccount
 	m.statusMsg = ""
 	m.errorMsg = ""
@@ -432,6 +713,12 @@ func (m model) handleFormInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "esc":
 		m.mode = viewTable
 		m.formInputs = nil
+		m.formErrors = nil
+		m.editingName = ""
+		if m.review.editing {
+			m.review.editing = false
+			m.mode = viewReview
+		}
 		return m, nil
 
 	case "tab", "down":
@@ -458,20 +745,43 @@ func (m model) handleFormInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		username := strings.TrimSpace(m.formInputs[1].Value())
 		email := strings.TrimSpace(m.formInputs[2].Value())
 		directory := strings.TrimSpace(m.formInputs[3].Value())
+		signing, err := m.formSigning()
+		if err != nil {
+			return m.showFormErrors("Invalid account", err), nil
+		}
 
-		if name == "" || username == "" || email == "" || directory == "" {
-			m.errorMsg = "❌ All fields are required"
-			return m, nil
+		if m.review.editing {
+			return m.saveEditedCandidate(name, username, email, directory, signing)
+		}
+
+		if m.editingName != "" {
+			return m.saveEditedAccount(name, username, email, directory, signing)
+		}
+
+		// Check the signing fields up front, since AddAccount doesn't take them
+		if err := (config.Account{Signing: signing}).Validate(); err != nil {
+			if invalid := signingErrors(err); len(invalid) > 0 {
+				return m.showFormErrors("Invalid account", invalid), nil
+			}
 		}
 
 		// Add account (this also saves the config)
-		if err := m.config.AddAccount(name, username, email, directory); err != nil {
-			m.errorMsg = fmt.Sprintf("❌ Failed to add account: %v", err)
-			return m, nil
+		if err := m.config.AddAccount(name, username, email, splitDirectories(directory)...); err != nil {
+			return m.showFormErrors("Failed to add account", err), nil
+		}
+		if signing != (config.Signing{}) {
+			if account, err := m.config.GetAccount(name); err == nil {
+				updated := *account
+				updated.Signing = signing
+				if err := m.config.UpdateAccount(name, updated); err != nil {
+					return m.showFormErrors("Failed to set signing", err), nil
+				}
+			}
 		}
 
 		m.mode = viewTable
 		m.formInputs = nil
+		m.formErrors = nil
 		m = m.refreshTable()
 		m.statusMsg = fmt.Sprintf("✓ Added account '%s'! Press 'a' to apply configs", name)
 		m.errorMsg = ""
@@ -490,14 +800,98 @@ func (m model) handleFormInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// formFields are the config fields edited by each form input, in order
+var formFields = []string{"name", "username", "email", "directories", "signing.mode", "signing.key", "signing.sign"}
+
+// formSigning reads the signing inputs of the account form
+func (m model) formSigning() (config.Signing, error) {
+	signing := config.Signing{
+		Mode: strings.ToLower(strings.TrimSpace(m.formInputs[4].Value())),
+		Key:  config.ExpandHome(strings.TrimSpace(m.formInputs[5].Value())),
+	}
+	for _, item := range strings.Split(m.formInputs[6].Value(), ",") {
+		switch strings.ToLower(strings.TrimSpace(item)) {
+		case "":
+		case "commits":
+			signing.SignCommits = true
+		case "tags":
+			signing.SignTags = true
+		default:
+			return signing, config.ValidationErrors{{Field: "signing.sign", Value: item, Reason: "list commits, tags or both"}}
+		}
+	}
+	return signing, nil
+}
+
+// setFormSigning fills the signing inputs of the account form
+func (m model) setFormSigning(signing config.Signing) model {
+	var sign []string
+	if signing.SignCommits {
+		sign = append(sign, "commits")
+	}
+	if signing.SignTags {
+		sign = append(sign, "tags")
+	}
+	m.formInputs[4].SetValue(signing.Mode)
+	m.formInputs[5].SetValue(signing.Key)
+	m.formInputs[6].SetValue(strings.Join(sign, ", "))
+	return m
+}
+
+// signingErrors picks the errors about signing fields out of a validation error
+func signingErrors(err error) config.ValidationErrors {
+	var invalid config.ValidationErrors
+	if errors.As(err, &invalid) {
+		invalid = slices.DeleteFunc(slices.Clone(invalid), func(e *config.FieldError) bool {
+			return !strings.HasPrefix(e.Field, "signing.")
+		})
+	}
+	return invalid
+}
+
+// splitDirectories parses the comma separated directories form input
+func splitDirectories(input string) []string {
+	return config.ExpandDirectories(strings.Split(input, ","))
+}
+
+// showFormErrors shows validation errors next to their inputs; errors for
+// fields the form doesn't edit, or other failures, go to the status line
+func (m model) showFormErrors(action string, err error) model {
+	var invalid config.ValidationErrors
+	if !errors.As(err, &invalid) {
+		m.formErrors = nil
+		m.errorMsg = fmt.Sprintf("❌ %s: %v", action, err)
+		return m
+	}
+
+	m.formErrors = invalid
+	m.errorMsg = "❌ Please fix the highlighted fields"
+	for _, e := range invalid {
+		if !slices.Contains(formFields, e.Field) {
+			m.errorMsg = fmt.Sprintf("❌ %s: %v", action, e)
+			break
+		}
+	}
+	return m
+}
+
 func (m model) renderAddAccountForm() string {
 	title := titleStyle.Render("Add New Account")
+	if m.editingName != "" {
+		title = titleStyle.Render(fmt.Sprintf("Edit Account '%s'", m.editingName))
+	} else if m.review.editing {
+		title = titleStyle.Render("Edit Detected Account")
+	}
 
 	var form strings.Builder
 	form.WriteString("\n")
 	for i, input := range m.formInputs {
 		form.WriteString(input.View())
 		form.WriteString("\n")
+		if e := m.formErrors.Field(formFields[i]); e != nil {
+			form.WriteString(errorStyle.Render("  ↳ " + e.Reason))
+			form.WriteString("\n")
+		}
 		if i < len(m.formInputs)-1 {
 			form.WriteString("\n")
 		}
@@ -518,87 +912,45 @@ func (m model) renderAddAccountForm() string {
 	)
 }
 
-func (m model) autoSync() model {
-	imported, err := m.config.ImportFromExistingSetup()
-	if err != nil {
-		m.errorMsg = fmt.Sprintf("❌ Auto-sync failed: %v", err)
-		m.statusMsg = ""
-		return m
-	}
-
-	if imported == 0 {
-		m.statusMsg = "No accounts found in .gitconfig or .ssh/config"
-		m.errorMsg = ""
-	} else {
-		m = m.refreshTable()
-		m.statusMsg = fmt.Sprintf("✓ Imported %d account(s) from existing setup!", imported)
-		m.errorMsg = ""
-	}
-
-	return m
-}
-
-func (m model) generateSSHKey() model {
-	if m.table.Cursor() < 0 {
+// copySigningKey copies the public half of the selected account's separate signing key
+func (m model) copySigningKey() model {
+	account, ok := m.selectedAccount()
+	if !ok {
 		m.errorMsg = "❌ No account selected"
 		m.statusMsg = ""
 		return m
 	}
-
-	accounts := m.config.ListAccounts()
-	if m.table.Cursor() >= len(accounts) {
-		return m
-	}
-
-	account := accounts[m.table.Cursor()]
-
-	// Check if key already exists
-	if _, err := os.Stat(account.SSHKeyPath); err == nil {
-		m.errorMsg = fmt.Sprintf("⚠️  SSH key already exists for %s", account.Name)
+	if !account.HasSeparateSigningKey() {
+		m.errorMsg = fmt.Sprintf("❌ %s has no separate signing key; press 'g' to generate one", account.Name)
 		m.statusMsg = ""
 		return m
 	}
-
-	// Generate the key
-	if err := m.sshManager.GenerateKey(account.SSHKeyPath, account.Email, "ed25519"); err != nil {
-		m.errorMsg = fmt.Sprintf("❌ Failed to generate key: %v", err)
+	pubKey, err := m.sshManager.GetPublicKey(account.SigningKeyPath)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("❌ No signing key found for %s", account.Name)
 		m.statusMsg = ""
 		return m
 	}
 
-	// Get and copy the public key
-	pubKey, err := m.sshManager.GetPublicKey(account.SSHKeyPath)
-	if err != nil {
-		m.errorMsg = fmt.Sprintf("❌ Failed to read public key: %v", err)
+	if err := clipboard.WriteAll(pubKey); err != nil {
+		m.errorMsg = "❌ Failed to copy to clipboard"
 		m.statusMsg = ""
 		return m
 	}
 
-	if err := clipboard.WriteAll(pubKey); err != nil {
-		m.statusMsg = fmt.Sprintf("✓ Key generated for %s! Press 'enter' to see details", account.Name)
-	} else {
-		m.statusMsg = fmt.Sprintf("✓ SSH key generated and copied! Add it to GitHub, then press 't' to test", account.Name)
-	}
+	m.statusMsg = fmt.Sprintf("✓ Signing key for %s copied! Add it on GitHub with Key type 'Signing Key'", account.Name)
 	m.errorMsg = ""
-	m = m.refreshTable()
-
 	return m
 }
 
 func (m model) testConnection() model {
-	if m.table.Cursor() < 0 {
+	account, ok := m.selectedAccount()
+	if !ok {
 		m.errorMsg = "❌ No account selected"
 		m.statusMsg = ""
 		return m
 	}
 
-	accounts := m.config.ListAccounts()
-	if m.table.Cursor() >= len(accounts) {
-		return m
-	}
-
-	account := accounts[m.table.Cursor()]
-
 	// Check if SSH key exists
 	if _, err := os.Stat(account.SSHKeyPath); err != nil {
 		m.errorMsg = fmt.Sprintf("❌ No SSH key found for %s. Press 'g' to generate one", account.Name)
@@ -609,13 +961,16 @@ func (m model) testConnection() model {
 	m.statusMsg = fmt.Sprintf("Testing connection for %s...", account.Name)
 
 	// Test the connection
-	success, message := m.sshManager.TestConnection(account.HostAlias)
+	success, message := m.sshManager.TestConnection(account)
 
 	if success {
 		m.statusMsg = fmt.Sprintf("✓ %s connected successfully!", account.Name)
 		m.errorMsg = ""
 	} else {
 		m.errorMsg = fmt.Sprintf("❌ Connection failed for %s: %s", account.Name, message)
+		if ssh.IsSecurityKey(account.SSHKeyPath) {
+			m.errorMsg += " (touch your security key when testing)"
+		}
 		m.statusMsg = ""
 	}
 
@@ -623,6 +978,21 @@ func (m model) testConnection() model {
 	return m
 }
 
+// tableStyles returns the styles shared by every table in the TUI
+func tableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderBottom(true).
+		Bold(true)
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color("229")).
+		Background(lipgloss.Color("57")).
+		Bold(false)
+	return s
+}
+
 // Run starts the TUI application
 func Run() error {
 	// Initialize managers
@@ -631,16 +1001,7 @@ func Run() error {
 		return fmt.Errorf("failed to initialize config: %w", err)
 	}
 
-	emptyStartup := false
-
-	// Auto-import from existing setup if no accounts configured
-	if len(cfg.ListAccounts()) == 0 {
-		emptyStartup = true
-		if imported, err := cfg.ImportFromExistingSetup(); err == nil && imported > 0 {
-			fmt.Printf("✨ Auto-detected %d account(s) from .gitconfig and .ssh/config\n", imported)
-			emptyStartup = false
-		}
-	}
+	emptyStartup := len(cfg.ListAccounts()) == 0
 
 	sshMgr, err := ssh.New()
 	if err != nil {
@@ -657,42 +1018,25 @@ func Run() error {
 		return fmt.Errorf("failed to initialize shell manager: %w", err)
 	}
 
-	// Create table
-	columns := []table.Column{
-		{Title: "Name", Width: 20},
-		{Title: "Username", Width: 20},
-		{Title: "Email", Width: 35},
-		{Title: "Directory", Width: 30},
-		{Title: "Status", Width: 12},
-	}
-
-	t := table.New(
-		table.WithColumns(columns),
-		table.WithFocused(true),
-		table.WithHeight(10),
-	)
-
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderBottom(true).
-		Bold(true)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
-		Bold(false)
-	t.SetStyles(s)
-
 	m := model{
-		table:        t,
 		config:       cfg,
 		sshManager:   sshMgr,
 		gitManager:   gitMgr,
 		shellManager: shellMgr,
 		emptyStartup: emptyStartup,
+		filterInput:  newFilterInput(),
 	}
 
+	// Create table, resized to the terminal on the first WindowSizeMsg
+	t := table.New(
+		table.WithColumns(m.columns()),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+
+	t.SetStyles(tableStyles())
+	m.table = t
+
 	// Load initial data or show welcome
 	if emptyStartup {
 		m.mode = viewTable
@@ -700,9 +1044,19 @@ func Run() error {
 	} else {
 		m.statusMsg = fmt.Sprintf("Loaded %d account(s) • Press 'a' to apply configs", len(cfg.ListAccounts()))
 	}
+	if err := cfg.Problems(); err != nil {
+		m.errorMsg = fmt.Sprintf("⚠️  %s has problems; press 'e' to fix them before applying: %v", cfg.ConfigFile(), err)
+	}
 
 	m = m.refreshTable()
 
+	// Offer detected accounts for review if none are configured yet
+	if emptyStartup {
+		if report, err := cfg.DetectionReport(); err == nil && len(report.Candidates) > 0 {
+			m = m.startReview(report)
+		}
+	}
+
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("error running program: %w", err)
@@ -0,0 +1,102 @@
+package workspace
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxRepoDepth bounds how far below a directory repositories are searched for
+const maxRepoDepth = 4
+
+// skipDirs are directories never worth descending into when looking for repositories
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+}
+
+// FindRepositories returns the paths of git repositories below root
+func FindRepositories(root string) ([]string, error) {
+	root = filepath.Clean(root)
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var repos []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+
+		if path != root {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || skipDirs[name] {
+				return filepath.SkipDir
+			}
+			if strings.Count(strings.TrimPrefix(path, root), string(filepath.Separator)) > maxRepoDepth {
+				return filepath.SkipDir
+			}
+		}
+
+		// .git is a directory for normal clones and a file for worktrees and submodules
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, path)
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+
+	return repos, nil
+}
+
+// Remote is a parsed git remote URL
+type Remote struct {
+	Host  string
+	Owner string
+	Repo  string
+}
+
+// OwnerRepo returns the remote as owner/repo
+func (r Remote) OwnerRepo() string {
+	return r.Owner + "/" + r.Repo
+}
+
+var (
+	// git@github.com-work:owner/repo.git
+	scpRemoteRe = regexp.MustCompile(`^[^@/]+@([^:/]+):([^/]+)/([^/]+?)(\.git)?/?$`)
+	// ssh://git@github.com/owner/repo.git, https://github.com/owner/repo
+	urlRemoteRe = regexp.MustCompile(`^[a-z+]+://(?:[^@/]+@)?([^/:]+)(?::\d+)?/([^/]+)/([^/]+?)(\.git)?/?$`)
+)
+
+// ParseRemote parses SSH, scp-style and HTTPS remote URLs
+func ParseRemote(url string) (Remote, bool) {
+	url = strings.TrimSpace(url)
+	for _, re := range []*regexp.Regexp{scpRemoteRe, urlRemoteRe} {
+		if matches := re.FindStringSubmatch(url); matches != nil {
+			return Remote{Host: matches[1], Owner: matches[2], Repo: matches[3]}, true
+		}
+	}
+	return Remote{}, false
+}
+
+var ownerRepoRe = regexp.MustCompile(`^([A-Za-z0-9_.-]+)/([A-Za-z0-9_.-]+?)(\.git)?$`)
+
+// ParseRepoSpec parses a remote URL or a bare owner/repo shorthand for github.com
+func ParseRepoSpec(spec string) (Remote, bool) {
+	spec = strings.TrimSpace(spec)
+	if remote, ok := ParseRemote(spec); ok {
+		return remote, true
+	}
+	if matches := ownerRepoRe.FindStringSubmatch(spec); matches != nil {
+		return Remote{Host: "github.com", Owner: matches[1], Repo: matches[2]}, true
+	}
+	return Remote{}, false
+}